@@ -6,19 +6,25 @@ import (
 	"fmt"
 	"net/http"
 	neturl "net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"github.com/tuvistavie/securerandom"
 
 	"github.com/sosedoff/pgweb/pkg/bookmarks"
+	"github.com/sosedoff/pgweb/pkg/chart"
 	"github.com/sosedoff/pgweb/pkg/client"
 	"github.com/sosedoff/pgweb/pkg/command"
 	"github.com/sosedoff/pgweb/pkg/connect"
 	"github.com/sosedoff/pgweb/pkg/connection"
+	"github.com/sosedoff/pgweb/pkg/history"
 	"github.com/sosedoff/pgweb/pkg/metrics"
 	"github.com/sosedoff/pgweb/pkg/queries"
+	"github.com/sosedoff/pgweb/pkg/scheduler"
 	"github.com/sosedoff/pgweb/pkg/shared"
 	"github.com/sosedoff/pgweb/static"
 )
@@ -30,10 +36,26 @@ var (
 	// DbSessions represents the mapping for client connections
 	DbSessions *SessionManager
 
+	// Jobs tracks queries running in the background
+	Jobs *JobManager
+
+	// Scheduler runs local queries with a schedule
+	Scheduler *scheduler.Scheduler
+
 	// QueryStore reads the SQL queries stores in the home directory
 	QueryStore *queries.Store
+
+	// BookmarkManager reads bookmarks, a new manager is used for every request when not set
+	BookmarkManager *bookmarks.Manager
 )
 
+func bookmarkManager() bookmarks.Manager {
+	if BookmarkManager != nil {
+		return *BookmarkManager
+	}
+	return bookmarks.NewManager(command.Opts.BookmarksDir)
+}
+
 // DB returns a database connection from the client context
 func DB(c *gin.Context) *client.Client {
 	if command.Opts.Sessions {
@@ -42,6 +64,12 @@ func DB(c *gin.Context) *client.Client {
 	return DbClient
 }
 
+// getConnContext returns the context of the request connection, used to check
+// local query restrictions. Tests replace it to avoid a database connection.
+var getConnContext = func(c *gin.Context) (*client.ConnContext, error) {
+	return DB(c).GetConnContext()
+}
+
 // setClient sets the database client connection for the sessions
 func setClient(c *gin.Context, newClient *client.Client) error {
 	currentClient := DB(c)
@@ -157,6 +185,8 @@ func Connect(c *gin.Context) {
 		cl, err = ConnectWithBookmark(bookmarkID)
 	} else if command.Opts.BookmarksOnly {
 		err = errNotPermitted
+	} else if service := c.Request.FormValue("service"); service != "" {
+		cl, err = ConnectWithService(service)
 	} else {
 		cl, err = ConnectWithURL(c)
 	}
@@ -207,9 +237,7 @@ func ConnectWithURL(c *gin.Context) (*client.Client, error) {
 }
 
 func ConnectWithBookmark(id string) (*client.Client, error) {
-	manager := bookmarks.NewManager(command.Opts.BookmarksDir)
-
-	bookmark, err := manager.Get(id)
+	bookmark, err := bookmarkManager().Get(id)
 	if err != nil {
 		return nil, err
 	}
@@ -217,6 +245,19 @@ func ConnectWithBookmark(id string) (*client.Client, error) {
 	return client.NewFromBookmark(bookmark)
 }
 
+// ConnectWithService creates a new client using the connection service file
+func ConnectWithService(name string) (*client.Client, error) {
+	url, err := connection.BuildStringFromOptions(command.Options{
+		Service:  name,
+		Passfile: command.Opts.Passfile,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return client.NewFromUrl(url, nil)
+}
+
 // SwitchDb perform database switch for the client connection
 func SwitchDb(c *gin.Context) {
 	if command.Opts.LockSession {
@@ -298,6 +339,10 @@ func Disconnect(c *gin.Context) {
 		return
 	}
 
+	if Jobs != nil {
+		Jobs.RemoveSession(getSessionId(c.Request))
+	}
+
 	err := conn.Close()
 	if err != nil {
 		badRequest(c, err)
@@ -317,7 +362,13 @@ func RunQuery(c *gin.Context) {
 		return
 	}
 
-	HandleQuery(query, c)
+	chart, err := getChartDefinition(c)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	handleQuery(query, nil, chart, c)
 }
 
 // ExplainQuery renders query explain plan
@@ -329,7 +380,9 @@ func ExplainQuery(c *gin.Context) {
 		return
 	}
 
-	HandleQuery(fmt.Sprintf("EXPLAIN %s", query), c)
+	handleExplain(c, query, DB(c).ExplainQuery, func(query string) {
+		HandleQuery("EXPLAIN "+query, c)
+	})
 }
 
 // AnalyzeQuery renders query explain plan and analyze profile
@@ -341,7 +394,30 @@ func AnalyzeQuery(c *gin.Context) {
 		return
 	}
 
-	HandleQuery(fmt.Sprintf("EXPLAIN ANALYZE %s", query), c)
+	// Analyzed queries are always rolled back, including the text plan fallback
+	handleExplain(c, query, DB(c).AnalyzeQuery, func(query string) {
+		res, err := DB(c).AnalyzeQueryText(query)
+		metrics.IncrementQueriesCount()
+		serveResult(c, res, err)
+	})
+}
+
+// handleExplain renders the parsed query plan, or falls back to the text plan
+// when the server does not support structured explain output.
+func handleExplain(c *gin.Context, query string, fn func(string) (*client.ExplainPlan, error), fallback func(string)) {
+	rawQuery, err := base64.StdEncoding.DecodeString(desanitize64(query))
+	if err == nil {
+		query = string(rawQuery)
+	}
+
+	plan, err := fn(query)
+	if err == client.ErrNotSupported {
+		fallback(query)
+		return
+	}
+
+	metrics.IncrementQueriesCount()
+	serveResult(c, plan, err)
 }
 
 // GetDatabases renders a list of all databases on the server
@@ -461,9 +537,62 @@ func GetTableInfo(c *gin.Context) {
 	}
 }
 
-// GetHistory renders a list of recent queries
+// GetHistory renders a page of recent queries, newest first
 func GetHistory(c *gin.Context) {
-	successResponse(c, DB(c).History)
+	offset, err := parseIntFormValue(c, "offset", 0)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	limit, err := parseIntFormValue(c, "limit", 100)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	if offset < 0 || limit < 0 {
+		badRequest(c, errInvalidPagination)
+		return
+	}
+
+	status := c.Request.FormValue("status")
+	if status != "" && status != "success" && status != "failed" {
+		badRequest(c, errInvalidHistoryStatus)
+		return
+	}
+
+	records, total, err := DB(c).History(history.Filter{
+		Search: c.Request.FormValue("search"),
+		Status: status,
+		Offset: offset,
+		Limit:  limit,
+	})
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	successResponse(c, gin.H{
+		"records": records,
+		"total":   total,
+		"offset":  offset,
+		"limit":   limit,
+	})
+}
+
+// DeleteHistoryRecord removes a single query from the history
+func DeleteHistoryRecord(c *gin.Context) {
+	err := DB(c).DeleteHistoryRecord(c.Param("id"))
+	if err == history.ErrRecordNotFound {
+		errorResponse(c, 404, err)
+		return
+	}
+	serveResult(c, gin.H{"success": err == nil}, err)
+}
+
+// ClearHistory removes all queries of the current connection from the history
+func ClearHistory(c *gin.Context) {
+	err := DB(c).ClearHistory()
+	serveResult(c, gin.H{"success": err == nil}, err)
 }
 
 // GetConnectionInfo renders information about current connection
@@ -484,6 +613,16 @@ func GetConnectionInfo(c *gin.Context) {
 	info := res.Format()[0]
 	info["session_lock"] = command.Opts.LockSession
 
+	// Server role is not available on all server types
+	if role, err := conn.ServerRole(); err == nil {
+		info["server_role"] = role
+	}
+
+	// Query stats require pg_stat_statements extension to be installed
+	if available, err := conn.QueryStatsAvailable(); err == nil {
+		info["query_stats"] = available
+	}
+
 	successResponse(c, info)
 }
 
@@ -499,6 +638,77 @@ func GetActivity(c *gin.Context) {
 	serveResult(c, res, err)
 }
 
+// CancelBackend cancels a running query of the backend process
+func CancelBackend(c *gin.Context) {
+	handleBackendAction(c, "cancel_backend", DB(c).CancelBackend)
+}
+
+// TerminateBackend terminates the backend process
+func TerminateBackend(c *gin.Context) {
+	handleBackendAction(c, "terminate_backend", DB(c).TerminateBackend)
+}
+
+func handleBackendAction(c *gin.Context, action string, fn func(int) (bool, error)) {
+	pid, err := strconv.Atoi(c.Param("pid"))
+	if err != nil {
+		badRequest(c, errInvalidPid)
+		return
+	}
+
+	// Backend actions are always recorded with the user information
+	fields := logrus.Fields{"action": action, "pid": pid}
+	addForwardedUserFields(c, fields)
+	addLogFields(c, fields)
+
+	result, err := fn(pid)
+	if err != nil {
+		if err == client.ErrBackendNotFound {
+			errorResponse(c, 404, err)
+			return
+		}
+		badRequest(c, err)
+		return
+	}
+
+	successResponse(c, gin.H{"pid": pid, "success": result})
+}
+
+// GetRecoveryStatus renders the server recovery status
+func GetRecoveryStatus(c *gin.Context) {
+	res, err := DB(c).RecoveryStatus()
+	serveResult(c, res, err)
+}
+
+// GetReplicas renders the streaming replication stats
+func GetReplicas(c *gin.Context) {
+	res, err := DB(c).Replicas()
+	serveResult(c, res, err)
+}
+
+// GetReplicationSlots renders a list of replication slots
+func GetReplicationSlots(c *gin.Context) {
+	res, err := DB(c).ReplicationSlots()
+	serveResult(c, res, err)
+}
+
+// GetWalReceiver renders the WAL receiver status
+func GetWalReceiver(c *gin.Context) {
+	res, err := DB(c).WalReceiver()
+	serveResult(c, res, err)
+}
+
+// GetPublications renders a list of logical replication publications
+func GetPublications(c *gin.Context) {
+	res, err := DB(c).Publications()
+	serveResult(c, res, err)
+}
+
+// GetSubscriptions renders a list of logical replication subscriptions
+func GetSubscriptions(c *gin.Context) {
+	res, err := DB(c).Subscriptions()
+	serveResult(c, res, err)
+}
+
 // GetTableIndexes renders a list of database table indexes
 func GetTableIndexes(c *gin.Context) {
 	res, err := DB(c).TableIndexes(c.Params.ByName("table"))
@@ -511,6 +721,58 @@ func GetTableConstraints(c *gin.Context) {
 	serveResult(c, res, err)
 }
 
+// GetIndexDiagnostics renders unused, duplicate, invalid and missing indexes reports
+func GetIndexDiagnostics(c *gin.Context) {
+	res, err := DB(c).IndexDiagnostics()
+	serveResult(c, res, err)
+}
+
+// GetBloatReport renders tables and indexes bloat estimates
+func GetBloatReport(c *gin.Context) {
+	res, err := DB(c).BloatReport()
+	serveResult(c, res, err)
+}
+
+// GetProgress renders the progress of running maintenance operations
+func GetProgress(c *gin.Context) {
+	res, err := DB(c).Progress()
+	serveResult(c, res, err)
+}
+
+// GetQueryStats renders normalized queries stats collected by pg_stat_statements
+func GetQueryStats(c *gin.Context) {
+	limit, err := parseIntFormValue(c, "limit", 100)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	opts := client.QueryStatsOptions{
+		User:       c.Request.FormValue("user"),
+		Database:   c.Request.FormValue("database"),
+		SortColumn: c.Request.FormValue("sort_column"),
+		SortOrder:  c.Request.FormValue("sort_order"),
+		Limit:      limit,
+	}
+
+	res, err := DB(c).QueryStats(opts)
+	serveResult(c, res, err)
+}
+
+// ResetQueryStats discards all stats collected by pg_stat_statements
+func ResetQueryStats(c *gin.Context) {
+	fields := logrus.Fields{"action": "reset_query_stats"}
+	addForwardedUserFields(c, fields)
+	addLogFields(c, fields)
+
+	if err := DB(c).ResetQueryStats(); err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	successResponse(c, gin.H{"success": true})
+}
+
 // GetTablesStats renders data sizes and estimated rows for all tables in the database
 func GetTablesStats(c *gin.Context) {
 	db := DB(c)
@@ -554,6 +816,12 @@ func GetTablesStats(c *gin.Context) {
 
 // HandleQuery runs the database query
 func HandleQuery(query string, c *gin.Context) {
+	handleQuery(query, nil, nil, c)
+}
+
+// handleQuery runs the query with positional arguments and renders the result.
+// Chart data is included into the result when the chart definition is given.
+func handleQuery(query string, args []interface{}, chart *chart.Definition, c *gin.Context) {
 	metrics.IncrementQueriesCount()
 
 	rawQuery, err := base64.StdEncoding.DecodeString(desanitize64(query))
@@ -561,14 +829,59 @@ func HandleQuery(query string, c *gin.Context) {
 		query = string(rawQuery)
 	}
 
-	result, err := DB(c).Query(query)
+	// Expensive queries must be explicitly confirmed by the user
+	if !isConfirmed(c) {
+		estimate, err := DB(c).CheckQueryCost(query, args...)
+		if err != nil {
+			c.AbortWithStatusJSON(400, gin.H{
+				"status":                400,
+				"error":                 err.Error(),
+				"estimate":              estimate,
+				"confirmation_required": true,
+			})
+			return
+		}
+	}
+
+	format := getQueryParam(c, "format")
+	filename := getQueryParam(c, "filename")
+
+	// Long running queries could be executed in the background and polled for results
+	if format == "" && isAsync(c) {
+		if Jobs == nil {
+			badRequest(c, errJobsDisabled)
+			return
+		}
+
+		job, err := Jobs.Start(getSessionId(c.Request), DB(c), query, args, isFullResult(c))
+		if err != nil {
+			badRequest(c, err)
+			return
+		}
+
+		successResponse(c, gin.H{"id": job.ID, "status": JobRunning})
+		return
+	}
+
+	// Row limit does not apply to exports, charts and explicit full result requests
+	run := DB(c).Query
+	if format != "" || isFullResult(c) || chart != nil {
+		run = DB(c).QueryAll
+	}
+
+	result, err := run(query, args...)
 	if err != nil {
 		badRequest(c, err)
 		return
 	}
 
-	format := getQueryParam(c, "format")
-	filename := getQueryParam(c, "filename")
+	if format == "" && chart != nil {
+		result.Chart, err = result.BuildChart(*chart)
+		if err != nil {
+			badRequest(c, err)
+			return
+		}
+	}
 
 	if filename == "" {
 		filename = fmt.Sprintf("pgweb-%v.%v", time.Now().Unix(), format)
@@ -590,22 +903,225 @@ func HandleQuery(query string, c *gin.Context) {
 	}
 }
 
+// GetJob renders the status and the paginated result of the asynchronous query
+func GetJob(c *gin.Context) {
+	if Jobs == nil {
+		badRequest(c, errJobsDisabled)
+		return
+	}
+
+	job := Jobs.Get(c.Param("id"), getSessionId(c.Request))
+	if job == nil {
+		errorResponse(c, 404, errJobNotFound)
+		return
+	}
+
+	offset, err := parseIntFormValue(c, "offset", 0)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	limit, err := parseIntFormValue(c, "limit", 0)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	if offset < 0 || limit < 0 {
+		badRequest(c, errInvalidPagination)
+		return
+	}
+
+	info := job.Info(Jobs.TTL(), offset, limit)
+	if Jobs.TTL() == 0 && info.Status != JobRunning {
+		Jobs.Remove(job.ID)
+	}
+
+	successResponse(c, info)
+}
+
 // GetBookmarks renders the list of available bookmarks
 func GetBookmarks(c *gin.Context) {
-	manager := bookmarks.NewManager(command.Opts.BookmarksDir)
-	ids, err := manager.ListIDs()
+	ids, err := bookmarkManager().ListIDs()
 	serveResult(c, ids, err)
 }
 
+// GetServices renders the list of connection services
+func GetServices(c *gin.Context) {
+	if command.Opts.BookmarksOnly {
+		successResponse(c, []string{})
+		return
+	}
+
+	names, err := connection.ServiceNames()
+	serveResult(c, names, err)
+}
+
+// GetBookmark renders the bookmark details without passwords
+func GetBookmark(c *gin.Context) {
+	bookmark, err := bookmarkManager().Get(c.Param("id"))
+	if err != nil {
+		errorResponse(c, 404, errBookmarkNotFound)
+		return
+	}
+
+	successResponse(c, newBookmarkInfo(*bookmark))
+}
+
+// CreateBookmark validates the connection and writes a new bookmark file
+func CreateBookmark(c *gin.Context) {
+	input := bookmarkInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	bookmark := input.bookmark()
+	if err := bookmark.Validate(); err != nil {
+		badRequest(c, err)
+		return
+	}
+	if err := testBookmarkConnection(bookmark); err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	manager := bookmarkManager()
+	if err := manager.Create(bookmark); err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	logBookmarkAction(c, "create_bookmark", bookmark.ID)
+	successResponse(c, newBookmarkInfo(bookmark))
+}
+
+// UpdateBookmark validates the connection and replaces an existing bookmark file.
+// Stored passwords are kept when not provided and the connection target is unchanged.
+func UpdateBookmark(c *gin.Context) {
+	input := bookmarkInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	manager := bookmarkManager()
+
+	current, err := manager.Get(c.Param("id"))
+	if err != nil {
+		errorResponse(c, 404, errBookmarkNotFound)
+		return
+	}
+
+	bookmark := input.bookmark()
+	if bookmark.ID == "" {
+		bookmark.ID = current.ID
+	}
+	bookmark.KeepPasswords(*current)
+
+	if err := bookmark.Validate(); err != nil {
+		badRequest(c, err)
+		return
+	}
+	if err := testBookmarkConnection(bookmark); err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	if err := manager.Update(current.ID, bookmark); err != nil {
+		if err == bookmarks.ErrBookmarkNotFound {
+			errorResponse(c, 404, errBookmarkNotFound)
+		} else {
+			badRequest(c, err)
+		}
+		return
+	}
+
+	logBookmarkAction(c, "update_bookmark", bookmark.ID)
+	successResponse(c, newBookmarkInfo(bookmark))
+}
+
+// DeleteBookmark removes the bookmark file
+func DeleteBookmark(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := bookmarkManager().Delete(id); err != nil {
+		if err == bookmarks.ErrBookmarkNotFound {
+			errorResponse(c, 404, errBookmarkNotFound)
+		} else {
+			badRequest(c, err)
+		}
+		return
+	}
+
+	logBookmarkAction(c, "delete_bookmark", id)
+	successResponse(c, gin.H{"success": true})
+}
+
+// testBookmarkConnection makes sure the bookmark connection works before it's saved
+func testBookmarkConnection(bookmark bookmarks.Bookmark) error {
+	conn, err := client.NewFromBookmark(&bookmark)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.TestWithTimeout(5 * time.Second)
+}
+
+func logBookmarkAction(c *gin.Context, action string, id string) {
+	fields := logrus.Fields{"action": action, "bookmark_id": id}
+	addForwardedUserFields(c, fields)
+	addLogFields(c, fields)
+}
+
+// GetConfigErrors renders errors of invalid bookmark and local query files.
+// Errors could include file contents, so only files that could be managed are included.
+func GetConfigErrors(c *gin.Context) {
+	result := gin.H{
+		"bookmarks": map[string]string{},
+		"queries":   map[string]string{},
+	}
+
+	if canManageBookmarks() {
+		bookmarkErrors, err := bookmarkManager().Errors()
+		if err != nil {
+			badRequest(c, err)
+			return
+		}
+		result["bookmarks"] = bookmarkErrors
+	}
+
+	if canManageQueries() {
+		queryErrors, err := QueryStore.Errors()
+		if err != nil && err != queries.ErrQueryDirNotExist {
+			badRequest(c, err)
+			return
+		}
+		if queryErrors != nil {
+			result["queries"] = queryErrors
+		}
+	}
+
+	successResponse(c, result)
+}
+
 // GetInfo renders the pgweb system information
 func GetInfo(c *gin.Context) {
 	successResponse(c, gin.H{
 		"app": command.Info,
 		"features": gin.H{
-			"session_lock":   command.Opts.LockSession,
-			"query_timeout":  command.Opts.QueryTimeout,
-			"local_queries":  QueryStore != nil,
-			"bookmarks_only": command.Opts.BookmarksOnly,
+			"session_lock":     command.Opts.LockSession,
+			"query_timeout":    command.Opts.QueryTimeout,
+			"local_queries":    QueryStore != nil,
+			"saved_queries":    QueryStore != nil && command.Opts.SavedQueries,
+			"dashboards":       QueryStore != nil,
+			"manage_bookmarks": command.Opts.ManageBookmarks && !command.Opts.BookmarksOnly,
+			"services":         !command.Opts.BookmarksOnly,
+			"queries_git":      QueryStore != nil && QueryStore.IsGit(),
+			"scheduler":        Scheduler != nil,
+			"async_queries":    Jobs != nil,
+			"bookmarks_only":   command.Opts.BookmarksOnly,
+			"backend_actions":  command.Opts.BackendActions && !command.Opts.ReadOnly,
 		},
 	})
 }
@@ -671,64 +1187,400 @@ func GetLocalQueries(c *gin.Context) {
 		return
 	}
 
-	queries := []localQuery{}
+	commits := map[string]queries.Commit{}
+	if QueryStore.IsGit() {
+		if commits, err = QueryStore.Commits(); err != nil {
+			badRequest(c, err)
+			return
+		}
+	}
+
+	result := []localQuery{}
 	for _, q := range storeQueries {
 		if !q.IsPermitted(connCtx.Host, connCtx.User, connCtx.Database, connCtx.Mode) {
 			continue
 		}
 
-		queries = append(queries, localQuery{
+		var commit *queries.Commit
+		if lastCommit, ok := commits[q.ID]; ok {
+			commit = &lastCommit
+		}
+
+		result = append(result, localQuery{
 			ID:          q.ID,
 			Title:       q.Meta.Title,
 			Description: q.Meta.Description,
+			Tags:        q.Meta.Tags,
+			Folder:      q.Meta.Folder,
+			Params:      q.Meta.Params,
+			Schedule:    q.Meta.Schedule,
+			Chart:       q.Meta.Chart,
+			Commit:      commit,
 			Query:       cleanQuery(q.Data),
 		})
 	}
 
-	successResponse(c, queries)
+	successResponse(c, result)
 }
 
 func RunLocalQuery(c *gin.Context) {
+	query := getPermittedLocalQuery(c)
+	if query == nil {
+		return
+	}
+
+	if c.Request.Method == http.MethodGet {
+		successResponse(c, localQuery{
+			ID:          query.ID,
+			Title:       query.Meta.Title,
+			Description: query.Meta.Description,
+			Tags:        query.Meta.Tags,
+			Folder:      query.Meta.Folder,
+			Params:      query.Meta.Params,
+			Schedule:    query.Meta.Schedule,
+			Chart:       query.Meta.Chart,
+			Query:       query.Data,
+		})
+		return
+	}
+
+	statement := cleanQuery(query.Data)
+	if statement == "" {
+		badRequest(c, errQueryRequired)
+		return
+	}
+
+	// Parameters are validated before the query is executed
+	args, err := query.BindParams(getParamValues(c))
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	// Chart from the request takes precedence over the query metadata
+	chart, err := getChartDefinition(c)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	if chart == nil {
+		chart = query.Meta.Chart
+	}
+
+	handleQuery(statement, args, chart, c)
+}
+
+// GetDashboards renders definitions of all dashboards
+func GetDashboards(c *gin.Context) {
+	dashboards, err := QueryStore.ReadAllDashboards()
+	serveResult(c, dashboards, err)
+}
+
+// GetDashboard runs all dashboard panels and renders their results
+func GetDashboard(c *gin.Context) {
+	dashboard, err := QueryStore.ReadDashboard(c.Param("id"))
+	if err != nil {
+		if err == queries.ErrDashboardFileNotExist {
+			errorResponse(c, 404, "dashboard not found")
+		} else {
+			badRequest(c, err)
+		}
+		return
+	}
+
+	result, err := runDashboard(DB(c), dashboard)
+	serveResult(c, result, err)
+}
+
+// GetSavedQueries renders definitions of local queries permitted for the current connection,
+// optionally filtered by folder or tag
+func GetSavedQueries(c *gin.Context) {
+	connCtx, err := getConnContext(c)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	storeQueries, err := QueryStore.ReadAll()
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	folder := c.Request.FormValue("folder")
+	tag := c.Request.FormValue("tag")
+
+	result := []queries.SavedQuery{}
+	for _, q := range storeQueries {
+		if !q.IsPermitted(connCtx.Host, connCtx.User, connCtx.Database, connCtx.Mode) {
+			continue
+		}
+		saved := queries.NewSavedQuery(q)
+
+		if folder != "" && saved.Folder != folder {
+			continue
+		}
+		if tag != "" && !slices.Contains(saved.Tags, tag) {
+			continue
+		}
+
+		result = append(result, saved)
+	}
+
+	successResponse(c, result)
+}
+
+// CreateSavedQuery writes a new local query file
+func CreateSavedQuery(c *gin.Context) {
+	input := queries.SavedQuery{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	// Scheduled queries run with the bookmark credentials and must be set up by the operator
+	if input.IsScheduled() {
+		errorResponse(c, 403, queries.ErrScheduledQuery)
+		return
+	}
+
+	// Limit new queries to the current server unless specified otherwise
+	if input.Host == "" {
+		connCtx, err := getConnContext(c)
+		if err != nil {
+			badRequest(c, err)
+			return
+		}
+		input.Host = connCtx.Host
+	}
+
+	query, err := QueryStore.Create(input)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	logSavedQueryAction(c, "create_saved_query", query.ID)
+	successResponse(c, queries.NewSavedQuery(*query))
+}
+
+// UpdateSavedQuery replaces an existing local query file
+func UpdateSavedQuery(c *gin.Context) {
+	input := queries.SavedQuery{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	if input.IsScheduled() {
+		errorResponse(c, 403, queries.ErrScheduledQuery)
+		return
+	}
+	if !checkUnscheduledQuery(c) {
+		return
+	}
+
+	query, err := QueryStore.Update(c.Param("id"), input)
+	if err != nil {
+		if err == queries.ErrQueryFileNotExist {
+			errorResponse(c, 404, "query not found")
+		} else {
+			badRequest(c, err)
+		}
+		return
+	}
+
+	logSavedQueryAction(c, "update_saved_query", query.ID)
+	successResponse(c, queries.NewSavedQuery(*query))
+}
+
+// DeleteSavedQuery removes the local query file
+func DeleteSavedQuery(c *gin.Context) {
+	id := c.Param("id")
+	if !checkUnscheduledQuery(c) {
+		return
+	}
+
+	if err := QueryStore.Delete(id); err != nil {
+		if err == queries.ErrQueryFileNotExist {
+			errorResponse(c, 404, "query not found")
+		} else {
+			badRequest(c, err)
+		}
+		return
+	}
+
+	logSavedQueryAction(c, "delete_saved_query", id)
+	successResponse(c, gin.H{"success": true})
+}
+
+// checkUnscheduledQuery renders an error if the existing query is not permitted for
+// the current connection or is run by the scheduler
+func checkUnscheduledQuery(c *gin.Context) bool {
+	query := getPermittedLocalQuery(c)
+	if query == nil {
+		return false
+	}
+
+	if queries.NewSavedQuery(*query).IsScheduled() {
+		errorResponse(c, 403, queries.ErrScheduledQuery)
+		return false
+	}
+
+	return true
+}
+
+func logSavedQueryAction(c *gin.Context, action string, id string) {
+	fields := logrus.Fields{"action": action, "query_id": id}
+	addForwardedUserFields(c, fields)
+	addLogFields(c, fields)
+}
+
+// getPermittedLocalQuery returns the local query if it's permitted for the current connection,
+// otherwise renders an error and returns nil.
+func getPermittedLocalQuery(c *gin.Context) *queries.Query {
 	query, err := QueryStore.Read(c.Param("id"))
 	if err != nil {
 		if err == queries.ErrQueryFileNotExist {
 			query = nil
 		} else {
 			badRequest(c, err)
-			return
+			return nil
 		}
 	}
 	if query == nil {
 		errorResponse(c, 404, "query not found")
-		return
+		return nil
 	}
 
-	connCtx, err := DB(c).GetConnContext()
+	connCtx, err := getConnContext(c)
 	if err != nil {
 		badRequest(c, err)
-		return
+		return nil
 	}
 
 	if !query.IsPermitted(connCtx.Host, connCtx.User, connCtx.Database, connCtx.Mode) {
 		errorResponse(c, 404, "query not found")
+		return nil
+	}
+
+	return query
+}
+
+// GetLocalQueryRuns renders a page of scheduled query runs, newest first
+func GetLocalQueryRuns(c *gin.Context) {
+	query := getPermittedLocalQuery(c)
+	if query == nil {
 		return
 	}
 
-	if c.Request.Method == http.MethodGet {
-		successResponse(c, localQuery{
-			ID:          query.ID,
-			Title:       query.Meta.Title,
-			Description: query.Meta.Description,
-			Query:       query.Data,
-		})
+	offset, err := parseIntFormValue(c, "offset", 0)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	limit, err := parseIntFormValue(c, "limit", 50)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	if offset < 0 || limit < 0 {
+		badRequest(c, errInvalidPagination)
 		return
 	}
 
-	statement := cleanQuery(query.Data)
-	if statement == "" {
-		badRequest(c, errQueryRequired)
+	runs, total, err := Scheduler.Snapshots().List(query.ID, offset, limit)
+	if err != nil {
+		badRequest(c, err)
 		return
 	}
 
-	HandleQuery(statement, c)
+	successResponse(c, gin.H{
+		"runs":     runs,
+		"total":    total,
+		"schedule": query.Meta.Schedule,
+		"next_run": Scheduler.NextRun(query.ID),
+	})
+}
+
+// GetLocalQueryRun renders the scheduled query run with the result snapshot
+func GetLocalQueryRun(c *gin.Context) {
+	query := getPermittedLocalQuery(c)
+	if query == nil {
+		return
+	}
+
+	run, err := Scheduler.Snapshots().Get(query.ID, c.Param("run_id"))
+	if err == scheduler.ErrRunNotFound {
+		errorResponse(c, 404, err)
+		return
+	}
+
+	serveResult(c, run, err)
+}
+
+// GetLocalQueryHistory renders git revisions of the local query, newest first
+func GetLocalQueryHistory(c *gin.Context) {
+	query := getPermittedLocalQuery(c)
+	if query == nil {
+		return
+	}
+
+	limit, err := parseIntFormValue(c, "limit", 50)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	if limit < 0 {
+		badRequest(c, errInvalidPagination)
+		return
+	}
+
+	commits, err := QueryStore.History(query.ID, limit)
+	serveResult(c, gin.H{"commits": commits}, err)
+}
+
+// GetLocalQueryRevision renders the local query at the given git revision
+func GetLocalQueryRevision(c *gin.Context) {
+	query := getPermittedLocalQuery(c)
+	if query == nil {
+		return
+	}
+
+	revision, err := QueryStore.ReadRevision(query.ID, c.Param("revision"))
+	if err != nil {
+		if err == queries.ErrQueryFileNotExist {
+			errorResponse(c, 404, "query revision not found")
+		} else {
+			badRequest(c, err)
+		}
+		return
+	}
+	if revision == nil {
+		errorResponse(c, 404, "query revision not found")
+		return
+	}
+
+	// Older revisions could have different connection restrictions
+	connCtx, err := DB(c).GetConnContext()
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	if !revision.IsPermitted(connCtx.Host, connCtx.User, connCtx.Database, connCtx.Mode) {
+		errorResponse(c, 404, "query revision not found")
+		return
+	}
+
+	successResponse(c, localQuery{
+		ID:          revision.ID,
+		Title:       revision.Meta.Title,
+		Description: revision.Meta.Description,
+		Tags:        revision.Meta.Tags,
+		Folder:      revision.Meta.Folder,
+		Params:      revision.Meta.Params,
+		Schedule:    revision.Meta.Schedule,
+		Chart:       revision.Meta.Chart,
+		Query:       revision.Data,
+	})
 }
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sosedoff/pgweb/pkg/client"
+	"github.com/sosedoff/pgweb/pkg/queries"
+)
+
+var (
+	errPanelQueryNotFound = errors.New("query not found")
+	errPanelTimeout       = errors.New("query timed out")
+)
+
+// runDashboard runs all dashboard panels concurrently against the connection.
+// Panel failures are reported per panel and do not fail the whole dashboard.
+func runDashboard(conn *client.Client, dashboard *queries.Dashboard) (*dashboardResult, error) {
+	connCtx, err := conn.GetConnContext()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dashboardResult{
+		Dashboard: *dashboard,
+		Panels:    make([]dashboardPanel, len(dashboard.Panels)),
+	}
+
+	var wg sync.WaitGroup
+	for i, panel := range dashboard.Panels {
+		wg.Add(1)
+		go func(i int, panel queries.Panel) {
+			defer wg.Done()
+			result.Panels[i] = runDashboardPanel(conn, connCtx, panel)
+		}(i, panel)
+	}
+	wg.Wait()
+
+	return result, nil
+}
+
+func runDashboardPanel(conn *client.Client, connCtx *client.ConnContext, panel queries.Panel) dashboardPanel {
+	start := time.Now()
+	query, res, err := executeDashboardPanel(conn, connCtx, panel)
+
+	result := dashboardPanel{
+		Panel:    panel,
+		Result:   res,
+		Duration: time.Since(start).Milliseconds(),
+	}
+	if query != nil {
+		if result.Title == "" {
+			result.Title = query.Meta.Title
+		}
+		result.Chart = query.Meta.Chart
+	}
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = errPanelTimeout
+		}
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+func executeDashboardPanel(conn *client.Client, connCtx *client.ConnContext, panel queries.Panel) (*queries.Query, *client.Result, error) {
+	// Panels are subject to the same restrictions as local queries
+	query, err := QueryStore.Read(panel.Query)
+	if err != nil && err != queries.ErrQueryFileNotExist {
+		return nil, nil, err
+	}
+	if query == nil || !query.IsPermitted(connCtx.Host, connCtx.User, connCtx.Database, connCtx.Mode) {
+		return nil, nil, errPanelQueryNotFound
+	}
+
+	// Panels could only use parameter defaults
+	args, err := query.BindParams(nil)
+	if err != nil {
+		return query, nil, err
+	}
+
+	// Panels could not be confirmed, so expensive queries are reported as panel errors
+	if _, err := conn.CheckQueryCost(cleanQuery(query.Data), args...); err != nil {
+		return query, nil, err
+	}
+
+	// Panel timeout is capped by the configured query timeout.
+	// Row limit does not apply to charts, same as for regular queries.
+	res, err := conn.QueryWithTimeout(cleanQuery(query.Data), panel.TimeoutDuration(), query.Meta.Chart != nil, args...)
+	if err != nil {
+		return query, nil, err
+	}
+
+	if query.Meta.Chart != nil {
+		if res.Chart, err = res.BuildChart(*query.Meta.Chart); err != nil {
+			return query, nil, err
+		}
+	}
+
+	return query, res, nil
+}
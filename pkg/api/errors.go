@@ -13,4 +13,13 @@ var (
 	errURLRequired          = errors.New("URL parameter is required")
 	errQueryRequired        = errors.New("Query parameter is required")
 	errDatabaseNameRequired = errors.New("Database name is required")
+	errInvalidPid           = errors.New("Process ID must be a number")
+	errInvalidPagination    = errors.New("Offset and limit must not be negative")
+	errInvalidHistoryStatus = errors.New("Status must be one of: success, failed")
+	errJobNotFound          = errors.New("Query job not found")
+	errJobsDisabled         = errors.New("Asynchronous queries are disabled")
+
+	errBookmarksManagementDisabled = errors.New("Bookmarks management is disabled")
+	errBookmarkNotFound            = errors.New("Bookmark not found")
+	errConfigManagementDisabled    = errors.New("Bookmarks and saved queries management is disabled")
 )
@@ -11,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/sosedoff/pgweb/pkg/chart"
 	"github.com/sosedoff/pgweb/pkg/shared"
 )
 
@@ -102,6 +103,57 @@ func getQueryParam(c *gin.Context, name string) string {
 	return result
 }
 
+// isConfirmed returns true if request explicitly confirms the action
+func isConfirmed(c *gin.Context) bool {
+	val, _ := strconv.ParseBool(c.Request.FormValue("confirm"))
+	return val
+}
+
+// isFullResult returns true if request asks for all rows regardless of the row limit
+func isFullResult(c *gin.Context) bool {
+	val, _ := strconv.ParseBool(c.Request.FormValue("full"))
+	return val
+}
+
+// isAsync returns true if request asks to run the query in the background
+func isAsync(c *gin.Context) bool {
+	val, _ := strconv.ParseBool(c.Request.FormValue("async"))
+	return val
+}
+
+// getParamValues returns local query parameters submitted as "params[name]" form values
+func getParamValues(c *gin.Context) map[string]string {
+	values := map[string]string{}
+
+	if err := c.Request.ParseForm(); err != nil {
+		return values
+	}
+
+	for key, val := range c.Request.Form {
+		if len(key) > 8 && strings.HasPrefix(key, "params[") && strings.HasSuffix(key, "]") && len(val) > 0 {
+			values[key[7:len(key)-1]] = val[0]
+		}
+	}
+
+	return values
+}
+
+// getChartDefinition returns the chart definition from the request, nil if chart is not requested
+func getChartDefinition(c *gin.Context) (*chart.Definition, error) {
+	chartType := c.Request.FormValue("chart")
+	if chartType == "" {
+		return nil, nil
+	}
+
+	return chart.NewDefinition(
+		chartType,
+		c.Request.FormValue("chart_x"),
+		c.Request.FormValue("chart_y"),
+		c.Request.FormValue("chart_series"),
+		c.Request.FormValue("chart_aggregate"),
+	)
+}
+
 func parseIntFormValue(c *gin.Context, name string, defValue int) (int, error) {
 	val := c.Request.FormValue(name)
 
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -86,3 +87,48 @@ func Test_serveResult(t *testing.T) {
 	assert.Equal(t, 200, w.Code)
 	assert.Equal(t, `null`, w.Body.String())
 }
+
+func Test_isConfirmed(t *testing.T) {
+	examples := map[string]bool{
+		"":      false,
+		"0":     false,
+		"false": false,
+		"foo":   false,
+		"1":     true,
+		"true":  true,
+	}
+
+	for given, expected := range examples {
+		t.Run(given, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request, _ = http.NewRequest("GET", "/?confirm="+given, nil)
+			assert.Equal(t, expected, isConfirmed(c))
+		})
+	}
+}
+
+func Test_isFullResult(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("GET", "/?full=true", nil)
+	assert.True(t, isFullResult(c))
+
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+	assert.False(t, isFullResult(c))
+}
+
+func Test_isAsync(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("GET", "/?async=1", nil)
+	assert.True(t, isAsync(c))
+
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+	assert.False(t, isAsync(c))
+}
+
+func Test_getParamValues(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("POST", "/?params[id]=1&format=csv", strings.NewReader("params%5Bname%5D=foo&params[]=bar"))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	assert.Equal(t, map[string]string{"id": "1", "name": "foo"}, getParamValues(c))
+}
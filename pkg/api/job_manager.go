@@ -0,0 +1,292 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tuvistavie/securerandom"
+
+	"github.com/sosedoff/pgweb/pkg/client"
+)
+
+const (
+	JobRunning  = "running"
+	JobFinished = "finished"
+	JobFailed   = "failed"
+)
+
+// Job represents a query running in the background
+type Job struct {
+	ID          string
+	SessionID   string
+	Query       string
+	StartedAt   time.Time
+	rowsFetched atomic.Int64
+
+	cancel     context.CancelFunc
+	mu         sync.Mutex
+	status     string
+	err        error
+	result     *client.Result
+	finishedAt time.Time
+}
+
+// JobInfo contains the job status and progress
+type JobInfo struct {
+	ID          string         `json:"id"`
+	Status      string         `json:"status"`
+	Query       string         `json:"query"`
+	Error       string         `json:"error,omitempty"`
+	RowsFetched int64          `json:"rows_fetched"`
+	Elapsed     int64          `json:"elapsed_ms"`
+	StartedAt   time.Time      `json:"started_at"`
+	FinishedAt  *time.Time     `json:"finished_at,omitempty"`
+	ExpiresAt   *time.Time     `json:"expires_at,omitempty"`
+	Result      *client.Result `json:"result,omitempty"`
+}
+
+// Info returns the job status with the requested page of the result
+func (j *Job) Info(ttl time.Duration, offset int, limit int) JobInfo {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	info := JobInfo{
+		ID:          j.ID,
+		Status:      j.status,
+		Query:       j.Query,
+		RowsFetched: j.rowsFetched.Load(),
+		StartedAt:   j.StartedAt,
+	}
+
+	if j.status == JobRunning {
+		info.Elapsed = time.Since(j.StartedAt).Milliseconds()
+		return info
+	}
+
+	finishedAt := j.finishedAt
+	info.FinishedAt = &finishedAt
+	info.Elapsed = finishedAt.Sub(j.StartedAt).Milliseconds()
+
+	expiresAt := finishedAt.Add(ttl)
+	info.ExpiresAt = &expiresAt
+
+	if j.err != nil {
+		info.Error = j.err.Error()
+	}
+	if j.result != nil {
+		info.Result = paginateResult(j.result, offset, limit)
+	}
+
+	return info
+}
+
+func (j *Job) finish(result *client.Result, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.result = result
+	j.err = err
+	j.finishedAt = time.Now()
+
+	if err != nil {
+		j.status = JobFailed
+	} else {
+		j.status = JobFinished
+	}
+}
+
+func (j *Job) stop() {
+	if j.cancel != nil {
+		j.cancel()
+	}
+}
+
+// expired returns true if job has finished more than ttl ago
+func (j *Job) expired(ttl time.Duration) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.status != JobRunning && time.Since(j.finishedAt) > ttl
+}
+
+type JobManager struct {
+	logger *logrus.Logger
+	jobs   map[string]*Job
+	mu     sync.Mutex
+	ttl    time.Duration
+}
+
+func NewJobManager(logger *logrus.Logger) *JobManager {
+	return &JobManager{
+		logger: logger,
+		jobs:   map[string]*Job{},
+		mu:     sync.Mutex{},
+	}
+}
+
+// SetTTL sets the expiration time of job results, results are removed once fetched when set to zero
+func (m *JobManager) SetTTL(ttl time.Duration) {
+	m.ttl = ttl
+}
+
+func (m *JobManager) TTL() time.Duration {
+	return m.ttl
+}
+
+// Start runs the query in the background and returns the new job
+func (m *JobManager) Start(sessionID string, conn *client.Client, query string, args []interface{}, full bool) (*Job, error) {
+	id, err := securerandom.Uuid()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	job := &Job{
+		ID:        id,
+		SessionID: sessionID,
+		Query:     query,
+		StartedAt: time.Now(),
+		cancel:    cancel,
+		status:    JobRunning,
+	}
+
+	m.mu.Lock()
+	m.jobs[id] = job
+	m.mu.Unlock()
+
+	go func() {
+		defer cancel()
+
+		result, err := conn.QueryWithProgress(ctx, query, full, func(rows int) {
+			job.rowsFetched.Store(int64(rows))
+		}, args...)
+		job.finish(result, err)
+
+		m.logger.WithField("id", id).WithField("status", job.Info(0, 0, 0).Status).Debug("query job finished")
+	}()
+
+	return job, nil
+}
+
+// Get returns the job if it belongs to the given session
+func (m *JobManager) Get(id string, sessionID string) *Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job := m.jobs[id]
+	if job == nil || job.SessionID != sessionID {
+		return nil
+	}
+
+	return job
+}
+
+// Remove cancels the job query if it's still running and removes the job
+func (m *JobManager) Remove(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if ok {
+		job.stop()
+		delete(m.jobs, id)
+	}
+
+	return ok
+}
+
+// RemoveSession cancels and removes all jobs of the session
+func (m *JobManager) RemoveSession(sessionID string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := 0
+	for id, job := range m.jobs {
+		if job.SessionID == sessionID {
+			job.stop()
+			delete(m.jobs, id)
+			removed++
+		}
+	}
+
+	return removed
+}
+
+func (m *JobManager) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.jobs)
+}
+
+// Cleanup removes finished jobs with expired results
+func (m *JobManager) Cleanup() int {
+	removed := 0
+	for _, id := range m.expiredJobs() {
+		if m.Remove(id) {
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		m.logger.Debug("removed expired query jobs:", removed)
+	}
+
+	return removed
+}
+
+func (m *JobManager) RunPeriodicCleanup() {
+	for range time.Tick(time.Minute) {
+		m.Cleanup()
+	}
+}
+
+func (m *JobManager) expiredJobs() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := []string{}
+	for id, job := range m.jobs {
+		if job.expired(m.ttl) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// paginateResult returns a copy of the result with a subset of rows
+func paginateResult(result *client.Result, offset int, limit int) *client.Result {
+	total := len(result.Rows)
+
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+
+	page := *result
+	page.Rows = result.Rows[offset:end]
+
+	if limit > 0 {
+		pages := int64(total / limit)
+		if pages*int64(limit) < int64(total) {
+			pages++
+		}
+
+		page.Pagination = &client.Pagination{
+			Rows:    int64(total),
+			Page:    int64(offset/limit) + 1,
+			Pages:   pages,
+			PerPage: int64(limit),
+		}
+	}
+
+	return &page
+}
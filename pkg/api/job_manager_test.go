@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sosedoff/pgweb/pkg/client"
+)
+
+func TestJobManager(t *testing.T) {
+	t.Run("get job", func(t *testing.T) {
+		manager := NewJobManager(nil)
+		assert.Nil(t, manager.Get("foo", ""))
+
+		manager.jobs["foo"] = &Job{ID: "foo", SessionID: "session"}
+		assert.Nil(t, manager.Get("foo", ""))
+		assert.Nil(t, manager.Get("foo", "other"))
+		assert.NotNil(t, manager.Get("foo", "session"))
+	})
+
+	t.Run("remove job", func(t *testing.T) {
+		manager := NewJobManager(nil)
+		assert.False(t, manager.Remove("foo"))
+
+		manager.jobs["foo"] = &Job{ID: "foo"}
+		assert.True(t, manager.Remove("foo"))
+		assert.Equal(t, 0, manager.Len())
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		manager := NewJobManager(logrus.New())
+		manager.jobs["running"] = &Job{ID: "running", status: JobRunning}
+		manager.jobs["recent"] = &Job{ID: "recent", status: JobFinished, finishedAt: time.Now()}
+		manager.jobs["expired"] = &Job{ID: "expired", status: JobFailed, finishedAt: time.Now().Add(-time.Hour)}
+
+		manager.SetTTL(time.Minute)
+		assert.Equal(t, 1, manager.Cleanup())
+		assert.Equal(t, 2, manager.Len())
+		assert.Nil(t, manager.Get("expired", ""))
+
+		// Results are not kept when expiration is disabled
+		manager.SetTTL(0)
+		assert.Equal(t, 1, manager.Cleanup())
+		assert.Equal(t, 1, manager.Len())
+		assert.NotNil(t, manager.Get("running", ""))
+	})
+
+	t.Run("remove session jobs", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		manager := NewJobManager(nil)
+		manager.jobs["foo"] = &Job{ID: "foo", SessionID: "session", cancel: cancel, status: JobRunning}
+		manager.jobs["bar"] = &Job{ID: "bar", SessionID: "session", status: JobFinished}
+		manager.jobs["baz"] = &Job{ID: "baz", SessionID: "other", status: JobRunning}
+
+		assert.Equal(t, 2, manager.RemoveSession("session"))
+		assert.Equal(t, 1, manager.Len())
+		assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	})
+}
+
+func TestJobInfo(t *testing.T) {
+	startedAt := time.Now().Add(-time.Second)
+
+	t.Run("running", func(t *testing.T) {
+		job := &Job{ID: "foo", Query: "SELECT 1", StartedAt: startedAt, status: JobRunning}
+		job.rowsFetched.Store(10)
+
+		info := job.Info(time.Minute, 0, 0)
+		assert.Equal(t, JobRunning, info.Status)
+		assert.Equal(t, int64(10), info.RowsFetched)
+		assert.GreaterOrEqual(t, info.Elapsed, int64(1000))
+		assert.Nil(t, info.FinishedAt)
+		assert.Nil(t, info.ExpiresAt)
+		assert.Nil(t, info.Result)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		job := &Job{ID: "foo", StartedAt: startedAt, status: JobRunning}
+		job.finish(nil, errors.New("query failed"))
+
+		info := job.Info(time.Minute, 0, 0)
+		assert.Equal(t, JobFailed, info.Status)
+		assert.Equal(t, "query failed", info.Error)
+		assert.Equal(t, info.FinishedAt.Add(time.Minute), *info.ExpiresAt)
+	})
+
+	t.Run("finished", func(t *testing.T) {
+		result := &client.Result{
+			Columns: []string{"id"},
+			Rows:    []client.Row{{1}, {2}, {3}, {4}, {5}},
+		}
+
+		job := &Job{ID: "foo", StartedAt: startedAt, status: JobRunning}
+		job.finish(result, nil)
+
+		info := job.Info(0, 0, 0)
+		assert.Equal(t, JobFinished, info.Status)
+		assert.Equal(t, *info.FinishedAt, *info.ExpiresAt)
+		assert.Equal(t, result.Rows, info.Result.Rows)
+		assert.Nil(t, info.Result.Pagination)
+
+		info = job.Info(0, 2, 2)
+		assert.Equal(t, []client.Row{{3}, {4}}, info.Result.Rows)
+		assert.Equal(t, &client.Pagination{Rows: 5, Page: 2, Pages: 3, PerPage: 2}, info.Result.Pagination)
+
+		info = job.Info(0, 10, 2)
+		assert.Empty(t, info.Result.Rows)
+	})
+}
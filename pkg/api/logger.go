@@ -12,6 +12,11 @@ import (
 	"github.com/sosedoff/pgweb/pkg/command"
 )
 
+const (
+	// Context key for extra fields included into the request log entry
+	logFieldsKey = "log_fields"
+)
+
 var (
 	logger *logrus.Logger
 
@@ -67,11 +72,12 @@ func RequestLogger(logger *logrus.Logger) gin.HandlerFunc {
 		}
 
 		if logForwardedUser {
-			if forwardedUser := c.GetHeader("X-Forwarded-User"); forwardedUser != "" {
-				fields["forwarded_user"] = forwardedUser
-			}
-			if forwardedEmail := c.GetHeader("X-Forwarded-Email"); forwardedEmail != "" {
-				fields["forwarded_email"] = forwardedEmail
+			addForwardedUserFields(c, fields)
+		}
+
+		if extraFields, ok := c.Get(logFieldsKey); ok {
+			for k, v := range extraFields.(logrus.Fields) {
+				fields[k] = v
 			}
 		}
 
@@ -102,6 +108,28 @@ func RequestLogger(logger *logrus.Logger) gin.HandlerFunc {
 	}
 }
 
+// addLogFields attaches extra fields to the request log entry
+func addLogFields(c *gin.Context, fields logrus.Fields) {
+	existing, ok := c.Get(logFieldsKey)
+	if !ok {
+		existing = logrus.Fields{}
+		c.Set(logFieldsKey, existing)
+	}
+
+	for k, v := range fields {
+		existing.(logrus.Fields)[k] = v
+	}
+}
+
+func addForwardedUserFields(c *gin.Context, fields logrus.Fields) {
+	if forwardedUser := c.GetHeader("X-Forwarded-User"); forwardedUser != "" {
+		fields["forwarded_user"] = forwardedUser
+	}
+	if forwardedEmail := c.GetHeader("X-Forwarded-Email"); forwardedEmail != "" {
+		fields["forwarded_email"] = forwardedEmail
+	}
+}
+
 func sanitizeLogPath(str string) string {
 	return reConnectToken.ReplaceAllString(str, "/connect/REDACTED")
 }
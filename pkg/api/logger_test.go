@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -29,3 +30,14 @@ func Test_getRequestID(t *testing.T) {
 		assert.Equal(t, ex.result, getRequestID(&gin.Context{Request: req}))
 	}
 }
+
+func Test_addLogFields(t *testing.T) {
+	c := &gin.Context{}
+
+	addLogFields(c, logrus.Fields{"action": "cancel_backend"})
+	addLogFields(c, logrus.Fields{"pid": 123})
+
+	fields, ok := c.Get(logFieldsKey)
+	assert.True(t, ok)
+	assert.Equal(t, logrus.Fields{"action": "cancel_backend", "pid": 123}, fields)
+}
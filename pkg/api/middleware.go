@@ -67,3 +67,81 @@ func requireLocalQueries() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+func requireGitQueries() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !QueryStore.IsGit() {
+			badRequest(c, "local queries are not backed by git")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func requireBookmarksManagement() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !canManageBookmarks() {
+			errorResponse(c, 403, errBookmarksManagementDisabled)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func requireConfigManagement() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !canManageBookmarks() && !canManageQueries() {
+			errorResponse(c, 403, errConfigManagementDisabled)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func canManageBookmarks() bool {
+	return command.Opts.ManageBookmarks && !command.Opts.BookmarksOnly
+}
+
+func canManageQueries() bool {
+	return QueryStore != nil && command.Opts.SavedQueries
+}
+
+func requireScheduler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if Scheduler == nil {
+			badRequest(c, "scheduled queries are disabled")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func requireSavedQueries() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !command.Opts.SavedQueries {
+			badRequest(c, "saved queries are disabled")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func requireBackendActions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !command.Opts.BackendActions {
+			badRequest(c, "backend actions are disabled")
+			return
+		}
+		if command.Opts.ReadOnly {
+			badRequest(c, "backend actions are not allowed in read-only mode")
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -37,6 +37,15 @@ func SetupRoutes(router *gin.Engine) {
 	api.GET("/connection", GetConnectionInfo)
 	api.GET("/server_settings", GetServerSettings)
 	api.GET("/activity", GetActivity)
+	api.GET("/progress", GetProgress)
+	api.GET("/replication/status", GetRecoveryStatus)
+	api.GET("/replication/replicas", GetReplicas)
+	api.GET("/replication/slots", GetReplicationSlots)
+	api.GET("/replication/wal_receiver", GetWalReceiver)
+	api.GET("/replication/publications", GetPublications)
+	api.GET("/replication/subscriptions", GetSubscriptions)
+	api.POST("/activity/:pid/cancel", requireBackendActions(), CancelBackend)
+	api.POST("/activity/:pid/terminate", requireBackendActions(), TerminateBackend)
 	api.GET("/schemas", GetSchemas)
 	api.GET("/objects", GetObjects)
 	api.GET("/tables/:table", GetTable)
@@ -45,19 +54,42 @@ func SetupRoutes(router *gin.Engine) {
 	api.GET("/tables/:table/indexes", GetTableIndexes)
 	api.GET("/tables/:table/constraints", GetTableConstraints)
 	api.GET("/tables_stats", GetTablesStats)
+	api.GET("/diagnostics/indexes", GetIndexDiagnostics)
+	api.GET("/diagnostics/bloat", GetBloatReport)
+	api.GET("/query_stats", GetQueryStats)
+	api.POST("/query_stats/reset", ResetQueryStats)
 	api.GET("/functions/:id", GetFunction)
 	api.GET("/query", RunQuery)
 	api.POST("/query", RunQuery)
+	api.GET("/jobs/:id", GetJob)
 	api.GET("/explain", ExplainQuery)
 	api.POST("/explain", ExplainQuery)
 	api.GET("/analyze", AnalyzeQuery)
 	api.POST("/analyze", AnalyzeQuery)
 	api.GET("/history", GetHistory)
+	api.DELETE("/history", ClearHistory)
+	api.DELETE("/history/:id", DeleteHistoryRecord)
 	api.GET("/bookmarks", GetBookmarks)
+	api.POST("/bookmarks", requireBookmarksManagement(), CreateBookmark)
+	api.GET("/bookmarks/:id", requireBookmarksManagement(), GetBookmark)
+	api.PUT("/bookmarks/:id", requireBookmarksManagement(), UpdateBookmark)
+	api.DELETE("/bookmarks/:id", requireBookmarksManagement(), DeleteBookmark)
+	api.GET("/services", GetServices)
+	api.GET("/config/errors", requireConfigManagement(), GetConfigErrors)
 	api.GET("/export", DataExport)
 	api.GET("/local_queries", requireLocalQueries(), GetLocalQueries)
 	api.GET("/local_queries/:id", requireLocalQueries(), RunLocalQuery)
 	api.POST("/local_queries/:id", requireLocalQueries(), RunLocalQuery)
+	api.GET("/local_queries/:id/runs", requireLocalQueries(), requireScheduler(), GetLocalQueryRuns)
+	api.GET("/local_queries/:id/runs/:run_id", requireLocalQueries(), requireScheduler(), GetLocalQueryRun)
+	api.GET("/local_queries/:id/history", requireLocalQueries(), requireGitQueries(), GetLocalQueryHistory)
+	api.GET("/local_queries/:id/history/:revision", requireLocalQueries(), requireGitQueries(), GetLocalQueryRevision)
+	api.GET("/dashboards", requireLocalQueries(), GetDashboards)
+	api.GET("/dashboards/:id", requireLocalQueries(), GetDashboard)
+	api.GET("/saved_queries", requireLocalQueries(), requireSavedQueries(), GetSavedQueries)
+	api.POST("/saved_queries", requireLocalQueries(), requireSavedQueries(), CreateSavedQuery)
+	api.PUT("/saved_queries/:id", requireLocalQueries(), requireSavedQueries(), UpdateSavedQuery)
+	api.DELETE("/saved_queries/:id", requireLocalQueries(), requireSavedQueries(), DeleteSavedQuery)
 }
 
 func SetupMetrics(engine *gin.Engine) {
@@ -74,6 +74,10 @@ func (m *SessionManager) Remove(id string) bool {
 
 	conn, ok := m.sessions[id]
 	if ok {
+		// Background queries must not outlive the session connection
+		if Jobs != nil {
+			Jobs.RemoveSession(id)
+		}
 		conn.Close()
 		delete(m.sessions, id)
 	}
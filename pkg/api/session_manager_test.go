@@ -51,6 +51,18 @@ func TestSessionManager(t *testing.T) {
 		assert.Nil(t, manager.Get("foo"))
 	})
 
+	t.Run("remove session jobs", func(t *testing.T) {
+		Jobs = NewJobManager(nil)
+		defer func() { Jobs = nil }()
+
+		manager := NewSessionManager(nil)
+		manager.Add("foo", &client.Client{})
+		Jobs.jobs["job"] = &Job{ID: "job", SessionID: "foo", status: JobRunning}
+
+		assert.True(t, manager.Remove("foo"))
+		assert.Equal(t, 0, Jobs.Len())
+	})
+
 	t.Run("return len", func(t *testing.T) {
 		manager := NewSessionManager(nil)
 		manager.sessions["foo"] = &client.Client{}
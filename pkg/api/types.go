@@ -1,8 +1,140 @@
 package api
 
+import (
+	"net/url"
+
+	"github.com/sosedoff/pgweb/pkg/bookmarks"
+	"github.com/sosedoff/pgweb/pkg/chart"
+	"github.com/sosedoff/pgweb/pkg/client"
+	"github.com/sosedoff/pgweb/pkg/queries"
+	"github.com/sosedoff/pgweb/pkg/shared"
+)
+
 type localQuery struct {
-	ID          string `json:"id"`
-	Title       string `json:"title,omitempty"`
-	Description string `json:"description,omitempty"`
-	Query       string `json:"query"`
+	ID          string            `json:"id"`
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Folder      string            `json:"folder,omitempty"`
+	Params      []queries.Param   `json:"params,omitempty"`
+	Schedule    string            `json:"schedule,omitempty"`
+	Chart       *chart.Definition `json:"chart,omitempty"`
+	Commit      *queries.Commit   `json:"commit,omitempty"`
+	Query       string            `json:"query"`
+}
+
+// dashboardResult contains the dashboard definition along with results of all panels
+type dashboardResult struct {
+	queries.Dashboard
+	Panels []dashboardPanel `json:"panels"`
+}
+
+type dashboardPanel struct {
+	queries.Panel
+	Chart    *chart.Definition `json:"chart,omitempty"`
+	Duration int64             `json:"duration_ms"`
+	Error    string            `json:"error,omitempty"`
+	Result   *client.Result    `json:"result,omitempty"`
+}
+
+// bookmarkInput contains bookmark fields of create and update requests.
+// Environment variables, password commands and secret references are evaluated
+// on the server and could only be set in bookmark files.
+type bookmarkInput struct {
+	ID           string          `json:"id"`
+	URL          string          `json:"url"`
+	Host         string          `json:"host"`
+	Port         int             `json:"port"`
+	User         string          `json:"user"`
+	UserVar      string          `json:"-"`
+	Password     string          `json:"password"`
+	PasswordVar  string          `json:"-"`
+	Database     string          `json:"database"`
+	SSLMode      string          `json:"sslmode"`
+	SSH          *shared.SSHInfo `json:"ssh"`
+	ReadOnly     bool            `json:"readonly"`
+	MaxQueryCost *float64        `json:"max_query_cost"`
+	MaxQueryRows *int64          `json:"max_query_rows"`
+}
+
+func (input bookmarkInput) bookmark() bookmarks.Bookmark {
+	return bookmarks.Bookmark{
+		ID:           input.ID,
+		URL:          input.URL,
+		Host:         input.Host,
+		Port:         input.Port,
+		User:         input.User,
+		UserVar:      input.UserVar,
+		Password:     input.Password,
+		PasswordVar:  input.PasswordVar,
+		Database:     input.Database,
+		SSLMode:      input.SSLMode,
+		SSH:          input.SSH,
+		ReadOnly:     input.ReadOnly,
+		MaxQueryCost: input.MaxQueryCost,
+		MaxQueryRows: input.MaxQueryRows,
+	}
+}
+
+// bookmarkInfo contains bookmark details without passwords
+type bookmarkInfo struct {
+	ID             string   `json:"id"`
+	URL            string   `json:"url,omitempty"`
+	Host           string   `json:"host,omitempty"`
+	Port           int      `json:"port,omitempty"`
+	User           string   `json:"user,omitempty"`
+	UserVar        string   `json:"user_var,omitempty"`
+	HasPassword    bool     `json:"has_password"`
+	PasswordVar    string   `json:"password_var,omitempty"`
+	Database       string   `json:"database,omitempty"`
+	SSLMode        string   `json:"sslmode,omitempty"`
+	SSHHost        string   `json:"ssh_host,omitempty"`
+	SSHPort        string   `json:"ssh_port,omitempty"`
+	SSHUser        string   `json:"ssh_user,omitempty"`
+	SSHKey         string   `json:"ssh_key,omitempty"`
+	HasSSHPassword bool     `json:"has_ssh_password"`
+	ReadOnly       bool     `json:"readonly"`
+	MaxQueryCost   *float64 `json:"max_query_cost,omitempty"`
+	MaxQueryRows   *int64   `json:"max_query_rows,omitempty"`
+}
+
+func newBookmarkInfo(b bookmarks.Bookmark) bookmarkInfo {
+	info := bookmarkInfo{
+		ID:           b.ID,
+		URL:          b.URL,
+		Host:         b.Host,
+		Port:         b.Port,
+		User:         b.User,
+		UserVar:      b.UserVar,
+		HasPassword:  b.Password != "" || b.PasswordCommand != "" || b.PasswordSecret != "",
+		PasswordVar:  b.PasswordVar,
+		Database:     b.Database,
+		SSLMode:      b.SSLMode,
+		ReadOnly:     b.ReadOnly,
+		MaxQueryCost: b.MaxQueryCost,
+		MaxQueryRows: b.MaxQueryRows,
+	}
+
+	// Connection URL could include the password as well
+	if u, err := url.Parse(b.URL); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			info.HasPassword = true
+			u.User = url.User(u.User.Username())
+			info.URL = u.String()
+		}
+	} else if err != nil {
+		info.URL = ""
+	}
+
+	if b.SSH != nil {
+		info.SSHHost = b.SSH.Host
+		info.SSHPort = b.SSH.Port
+		info.SSHUser = b.SSH.User
+		info.SSHKey = b.SSH.Key
+		info.HasSSHPassword = b.SSH.Password != "" || b.SSH.KeyPassword != "" ||
+			b.SSH.PasswordCommand != "" || b.SSH.PasswordSecret != "" ||
+			b.SSH.KeyPasswordCommand != "" || b.SSH.KeyPasswordSecret != ""
+	}
+
+	return info
 }
@@ -1,26 +1,241 @@
 package bookmarks
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	neturl "net/url"
 	"os"
+	"regexp"
+	"slices"
+
+	"github.com/BurntSushi/toml"
 
 	"github.com/sosedoff/pgweb/pkg/command"
 	"github.com/sosedoff/pgweb/pkg/shared"
 )
 
+var (
+	ErrBookmarkExists    = errors.New("bookmark already exists")
+	ErrBookmarkNotFound  = errors.New("bookmark not found")
+	ErrInvalidBookmarkID = errors.New("bookmark id must only contain letters, numbers, dashes and underscores")
+
+	reBookmarkID = regexp.MustCompile(`^[\w\-]+$`)
+
+	// List of all supported postgres modes
+	sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+)
+
 // Bookmark contains information about bookmarked database connection
 type Bookmark struct {
-	ID          string          // ID generated from the filename
-	URL         string          // Postgres connection URL
-	Host        string          // Server hostname
-	Port        int             // Server port
-	User        string          // Database user
-	UserVar     string          // Database user environment variable
-	Password    string          // User password
-	PasswordVar string          // User password environment variable
-	Database    string          // Database name
-	SSLMode     string          // Connection SSL mode
-	SSH         *shared.SSHInfo // SSH tunnel config
-	ReadOnly    bool            // Enable read-only transaction mode
+	ID              string          // ID generated from the filename
+	URL             string          // Postgres connection URL
+	Host            string          // Server hostname
+	Port            int             // Server port
+	User            string          // Database user
+	UserVar         string          // Database user environment variable
+	Password        string          // User password
+	PasswordVar     string          // User password environment variable
+	PasswordCommand string          `toml:"password_command"` // Command to print the user password
+	PasswordSecret  string          `toml:"password_secret"`  // User password secret reference, ie "vault:secret/data/db#password"
+	Database        string          // Database name
+	SSLMode         string          // Connection SSL mode
+	SSH             *shared.SSHInfo // SSH tunnel config
+	ReadOnly        bool            // Enable read-only transaction mode
+	MaxQueryCost    *float64        // Maximum planner estimated cost of ad-hoc queries, overrides the global limit when set
+	MaxQueryRows    *int64          // Maximum planner estimated rows of ad-hoc queries, overrides the global limit when set
+}
+
+// bookmarkFile defines the layout of bookmark files written by the manager.
+// Keys match bookmark fields when files are decoded.
+type bookmarkFile struct {
+	URL             string   `toml:"url,omitempty"`
+	Host            string   `toml:"host,omitempty"`
+	Port            int      `toml:"port,omitempty"`
+	User            string   `toml:"user,omitempty"`
+	UserVar         string   `toml:"uservar,omitempty"`
+	Password        string   `toml:"password,omitempty"`
+	PasswordVar     string   `toml:"passwordvar,omitempty"`
+	PasswordCommand string   `toml:"password_command,omitempty"`
+	PasswordSecret  string   `toml:"password_secret,omitempty"`
+	Database        string   `toml:"database,omitempty"`
+	SSLMode         string   `toml:"sslmode,omitempty"`
+	ReadOnly        bool     `toml:"readonly,omitempty"`
+	MaxQueryCost    *float64 `toml:"maxquerycost,omitempty"`
+	MaxQueryRows    *int64   `toml:"maxqueryrows,omitempty"`
+	SSH             *sshFile `toml:"ssh,omitempty"`
+}
+
+type sshFile struct {
+	Host        string `toml:"host,omitempty"`
+	Port        string `toml:"port,omitempty"`
+	User        string `toml:"user,omitempty"`
+	Password    string `toml:"password,omitempty"`
+	Key         string `toml:"key,omitempty"`
+	KeyPassword string `toml:"keypassword,omitempty"`
+
+	PasswordCommand    string `toml:"password_command,omitempty"`
+	PasswordSecret     string `toml:"password_secret,omitempty"`
+	KeyPasswordCommand string `toml:"keypassword_command,omitempty"`
+	KeyPasswordSecret  string `toml:"keypassword_secret,omitempty"`
+}
+
+// Validate checks if the bookmark could be saved
+func (b Bookmark) Validate() error {
+	if !reBookmarkID.MatchString(b.ID) {
+		return ErrInvalidBookmarkID
+	}
+	if b.URL == "" && b.Host == "" {
+		return errors.New("bookmark url or host must be set")
+	}
+	if b.Port < 0 || b.Port > 65535 {
+		return fmt.Errorf("invalid bookmark port: %d", b.Port)
+	}
+	if b.SSLMode != "" && !slices.Contains(sslModes, b.SSLMode) {
+		return fmt.Errorf("invalid bookmark ssl mode: %q", b.SSLMode)
+	}
+	if (b.MaxQueryCost != nil && *b.MaxQueryCost < 0) || (b.MaxQueryRows != nil && *b.MaxQueryRows < 0) {
+		return errors.New("bookmark query cost limits must not be negative")
+	}
+	return nil
+}
+
+// KeepPasswords copies passwords and password sources of the stored bookmark
+// when new ones are not provided. Stored secrets are only kept when they would be
+// sent to the same server, so changing the connection target requires new ones.
+func (b *Bookmark) KeepPasswords(stored Bookmark) {
+	sameSSH := sameSSHTarget(b.SSH, stored.SSH)
+
+	if sameSSH && b.sameTarget(stored) {
+		if b.Password == "" && b.PasswordVar == "" && b.PasswordCommand == "" && b.PasswordSecret == "" {
+			b.Password = stored.Password
+			b.PasswordVar = stored.PasswordVar
+			b.PasswordCommand = stored.PasswordCommand
+			b.PasswordSecret = stored.PasswordSecret
+		}
+		b.URL = keepURLPassword(b.URL, stored.URL)
+	}
+
+	if sameSSH && b.SSH != nil && stored.SSH != nil {
+		if b.SSH.Password == "" && b.SSH.PasswordCommand == "" && b.SSH.PasswordSecret == "" {
+			b.SSH.Password = stored.SSH.Password
+			b.SSH.PasswordCommand = stored.SSH.PasswordCommand
+			b.SSH.PasswordSecret = stored.SSH.PasswordSecret
+		}
+		if b.SSH.KeyPassword == "" && b.SSH.KeyPasswordCommand == "" && b.SSH.KeyPasswordSecret == "" {
+			b.SSH.KeyPassword = stored.SSH.KeyPassword
+			b.SSH.KeyPasswordCommand = stored.SSH.KeyPasswordCommand
+			b.SSH.KeyPasswordSecret = stored.SSH.KeyPasswordSecret
+		}
+	}
+}
+
+// sameTarget checks if the bookmark connects to the same server with the same user
+func (b Bookmark) sameTarget(other Bookmark) bool {
+	return b.Host == other.Host &&
+		bookmarkPort(b.Port) == bookmarkPort(other.Port) &&
+		b.User == other.User &&
+		b.UserVar == other.UserVar &&
+		urlTarget(b.URL) == urlTarget(other.URL)
+}
+
+func sameSSHTarget(a *shared.SSHInfo, b *shared.SSHInfo) bool {
+	empty := shared.SSHInfo{}
+	if a == nil {
+		a = &empty
+	}
+	if b == nil {
+		b = &empty
+	}
+	return a.Host == b.Host && a.Port == b.Port && a.User == b.User && a.Key == b.Key
+}
+
+func bookmarkPort(port int) int {
+	if port == 0 {
+		return 5432
+	}
+	return port
+}
+
+// urlTarget returns the connection URL without the password and database name,
+// query parameters are included since they could override the host or user
+func urlTarget(value string) string {
+	u, err := neturl.Parse(value)
+	if err != nil {
+		return value
+	}
+	if u.User != nil {
+		u.User = neturl.User(u.User.Username())
+	}
+	u.Path = ""
+	u.RawPath = ""
+
+	return u.String()
+}
+
+// keepURLPassword adds the password of the stored connection URL to the new URL
+// when the new URL has a user without a password
+func keepURLPassword(newURL string, storedURL string) string {
+	stored, err := neturl.Parse(storedURL)
+	if err != nil || stored.User == nil {
+		return newURL
+	}
+	password, ok := stored.User.Password()
+	if !ok {
+		return newURL
+	}
+
+	u, err := neturl.Parse(newURL)
+	if err != nil || u.User == nil {
+		return newURL
+	}
+	if _, ok := u.User.Password(); ok {
+		return newURL
+	}
+
+	u.User = neturl.UserPassword(u.User.Username(), password)
+	return u.String()
+}
+
+// encodeBookmark returns the bookmark file content
+func encodeBookmark(b Bookmark) ([]byte, error) {
+	file := bookmarkFile{
+		URL:             b.URL,
+		Host:            b.Host,
+		Port:            b.Port,
+		User:            b.User,
+		UserVar:         b.UserVar,
+		Password:        b.Password,
+		PasswordVar:     b.PasswordVar,
+		PasswordCommand: b.PasswordCommand,
+		PasswordSecret:  b.PasswordSecret,
+		Database:        b.Database,
+		SSLMode:         b.SSLMode,
+		ReadOnly:        b.ReadOnly,
+		MaxQueryCost:    b.MaxQueryCost,
+		MaxQueryRows:    b.MaxQueryRows,
+	}
+	if !b.SSHInfoIsEmpty() {
+		file.SSH = &sshFile{
+			Host:        b.SSH.Host,
+			Port:        b.SSH.Port,
+			User:        b.SSH.User,
+			Password:    b.SSH.Password,
+			Key:         b.SSH.Key,
+			KeyPassword: b.SSH.KeyPassword,
+
+			PasswordCommand:    b.SSH.PasswordCommand,
+			PasswordSecret:     b.SSH.PasswordSecret,
+			KeyPasswordCommand: b.SSH.KeyPasswordCommand,
+			KeyPasswordSecret:  b.SSH.KeyPasswordSecret,
+		}
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := toml.NewEncoder(buf).Encode(file); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
 // SSHInfoIsEmpty returns true if ssh configuration is not provided
@@ -28,8 +243,10 @@ func (b Bookmark) SSHInfoIsEmpty() bool {
 	return b.SSH == nil || (b.SSH.User == "" && b.SSH.Host == "" && b.SSH.Port == "")
 }
 
-// ConvertToOptions returns an options struct from connection details
-func (b Bookmark) ConvertToOptions() command.Options {
+// ConvertToOptions returns an options struct from connection details.
+// Encrypted password is decrypted with the configured keyring, password command
+// and secret reference are used when password is not set.
+func (b Bookmark) ConvertToOptions() (command.Options, error) {
 	user := b.User
 	if b.User == "" {
 		user = os.Getenv(b.UserVar)
@@ -40,6 +257,11 @@ func (b Bookmark) ConvertToOptions() command.Options {
 		pass = os.Getenv(b.PasswordVar)
 	}
 
+	pass, err := resolvePassword(pass, b.PasswordCommand, b.PasswordSecret)
+	if err != nil {
+		return command.Options{}, err
+	}
+
 	return command.Options{
 		URL:      b.URL,
 		Host:     b.Host,
@@ -49,5 +271,5 @@ func (b Bookmark) ConvertToOptions() command.Options {
 		DbName:   b.Database,
 		SSLMode:  b.SSLMode,
 		ReadOnly: b.ReadOnly,
-	}
+	}, nil
 }
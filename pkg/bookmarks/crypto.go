@@ -0,0 +1,275 @@
+package bookmarks
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+const (
+	// Prefix of all encrypted values
+	encryptedPrefix = "enc:"
+
+	// Values encrypted with AES-256-GCM using the master key
+	aesPrefix = encryptedPrefix + "aes:"
+
+	// Values encrypted with the age X25519 recipient
+	agePrefix = encryptedPrefix + "age:"
+)
+
+var (
+	ErrKeyNotConfigured = errors.New("bookmark password is encrypted but master key is not configured")
+	ErrInvalidKey       = errors.New("master key must be 32 bytes encoded with base64")
+	ErrDecrypt          = errors.New("unable to decrypt bookmark password")
+
+	// Keyring used to decrypt bookmark passwords
+	keyring *Keyring
+)
+
+// Keyring encrypts and decrypts bookmark passwords with the master key or age identities
+type Keyring struct {
+	key        []byte
+	identities []age.Identity
+	recipient  age.Recipient
+}
+
+// SetKeyring assigns the keyring used to decrypt bookmark passwords
+func SetKeyring(k *Keyring) {
+	keyring = k
+}
+
+// LoadKeyring returns a keyring from the base64 encoded key, key file or age identity file.
+// Nil keyring is returned when none of the sources are provided.
+func LoadKeyring(key string, keyFile string, identityFile string) (*Keyring, error) {
+	if key == "" && keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read master key file: %w", err)
+		}
+		key = string(data)
+	}
+
+	var (
+		k   *Keyring
+		err error
+	)
+
+	if key != "" {
+		if k, err = NewKeyring(key); err != nil {
+			return nil, err
+		}
+	}
+
+	if identityFile != "" {
+		file, err := os.Open(identityFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read identity file: %w", err)
+		}
+		defer file.Close()
+
+		ageKeyring, err := NewAgeKeyring(file)
+		if err != nil {
+			return nil, err
+		}
+
+		// Values encrypted with the master key could be still decrypted
+		if k != nil {
+			ageKeyring.key = k.key
+		}
+		k = ageKeyring
+	}
+
+	return k, nil
+}
+
+// NewKeyring returns a keyring for the base64 encoded 32 bytes master key
+func NewKeyring(key string) (*Keyring, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil || len(data) != 32 {
+		return nil, ErrInvalidKey
+	}
+
+	return &Keyring{key: data}, nil
+}
+
+// NewAgeKeyring returns a keyring for X25519 identities of the age identity file.
+// Values are encrypted to the first identity of the file.
+func NewAgeKeyring(r io.Reader) (*Keyring, error) {
+	identities, err := age.ParseIdentities(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid identity file: %w", err)
+	}
+
+	k := &Keyring{identities: identities}
+
+	for _, identity := range identities {
+		if x25519, ok := identity.(*age.X25519Identity); ok {
+			k.recipient = x25519.Recipient()
+			break
+		}
+	}
+	if k.recipient == nil {
+		return nil, errors.New("invalid identity file: X25519 identity is not found")
+	}
+
+	return k, nil
+}
+
+// IsEncrypted returns true if the value is encrypted
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// Encrypt returns the encrypted value. Age recipient is preferred over the master key.
+func (k *Keyring) Encrypt(value string) (string, error) {
+	if value == "" || IsEncrypted(value) {
+		return value, nil
+	}
+
+	if k.recipient != nil {
+		buf := bytes.NewBuffer(nil)
+
+		w, err := age.Encrypt(buf, k.recipient)
+		if err != nil {
+			return "", err
+		}
+		if _, err := io.WriteString(w, value); err != nil {
+			return "", err
+		}
+		if err := w.Close(); err != nil {
+			return "", err
+		}
+
+		return agePrefix + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+	}
+
+	gcm, err := k.gcm()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	data := gcm.Seal(nonce, nonce, []byte(value), nil)
+	return aesPrefix + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// Decrypt returns the decrypted value, plain values are returned as is
+func (k *Keyring) Decrypt(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, agePrefix):
+		if len(k.identities) == 0 {
+			return "", ErrKeyNotConfigured
+		}
+
+		data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, agePrefix))
+		if err != nil {
+			return "", ErrDecrypt
+		}
+
+		r, err := age.Decrypt(bytes.NewReader(data), k.identities...)
+		if err != nil {
+			return "", ErrDecrypt
+		}
+
+		result, err := io.ReadAll(r)
+		if err != nil {
+			return "", ErrDecrypt
+		}
+		return string(result), nil
+	case strings.HasPrefix(value, aesPrefix):
+		if k.key == nil {
+			return "", ErrKeyNotConfigured
+		}
+
+		data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, aesPrefix))
+		if err != nil {
+			return "", ErrDecrypt
+		}
+
+		gcm, err := k.gcm()
+		if err != nil {
+			return "", err
+		}
+		if len(data) < gcm.NonceSize() {
+			return "", ErrDecrypt
+		}
+
+		result, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+		if err != nil {
+			return "", ErrDecrypt
+		}
+		return string(result), nil
+	case IsEncrypted(value):
+		return "", fmt.Errorf("%w: unsupported encryption", ErrDecrypt)
+	}
+
+	return value, nil
+}
+
+func (k *Keyring) gcm() (cipher.AEAD, error) {
+	if k.key == nil {
+		return nil, ErrKeyNotConfigured
+	}
+
+	block, err := aes.NewCipher(k.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// decrypt returns the decrypted value using the configured keyring
+func decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if keyring == nil {
+		return "", ErrKeyNotConfigured
+	}
+	return keyring.Decrypt(value)
+}
+
+// Encrypt returns a copy of the bookmark with encrypted passwords.
+// Returns true if any of the passwords were not encrypted before.
+func (b Bookmark) Encrypt(k *Keyring) (Bookmark, bool, error) {
+	var (
+		changed bool
+		err     error
+	)
+
+	encrypt := func(value string) string {
+		if err != nil || value == "" || IsEncrypted(value) {
+			return value
+		}
+
+		var result string
+		if result, err = k.Encrypt(value); err == nil {
+			changed = true
+		}
+		return result
+	}
+
+	b.Password = encrypt(b.Password)
+	if b.SSH != nil {
+		info := *b.SSH
+		info.Password = encrypt(info.Password)
+		info.KeyPassword = encrypt(info.KeyPassword)
+		b.SSH = &info
+	}
+
+	return b, changed, err
+}
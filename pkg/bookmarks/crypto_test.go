@@ -0,0 +1,201 @@
+package bookmarks
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sosedoff/pgweb/pkg/shared"
+)
+
+func testKey() string {
+	return base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+}
+
+func TestLoadKeyring(t *testing.T) {
+	t.Run("not configured", func(t *testing.T) {
+		k, err := LoadKeyring("", "", "")
+		assert.NoError(t, err)
+		assert.Nil(t, k)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := LoadKeyring("foo", "", "")
+		assert.Equal(t, ErrInvalidKey, err)
+	})
+
+	t.Run("key file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "key")
+		require.NoError(t, os.WriteFile(path, []byte(testKey()+"\n"), 0600))
+
+		k, err := LoadKeyring("", path, "")
+		assert.NoError(t, err)
+		assert.NotNil(t, k)
+	})
+
+	t.Run("missing identity file", func(t *testing.T) {
+		_, err := LoadKeyring("", "", "/tmp/missing-identity")
+		assert.ErrorContains(t, err, "unable to read identity file")
+	})
+}
+
+func TestKeyring(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "identity.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# test\n"+identity.String()+"\n"), 0600))
+
+	aesKeyring, err := LoadKeyring(testKey(), "", "")
+	require.NoError(t, err)
+
+	ageKeyring, err := LoadKeyring(testKey(), "", path)
+	require.NoError(t, err)
+
+	t.Run("master key", func(t *testing.T) {
+		value, err := aesKeyring.Encrypt("secret")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(value, "enc:aes:"))
+
+		result, err := aesKeyring.Decrypt(value)
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", result)
+
+		// Both keys are available
+		result, err = ageKeyring.Decrypt(value)
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", result)
+	})
+
+	t.Run("age identity", func(t *testing.T) {
+		value, err := ageKeyring.Encrypt("secret")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(value, "enc:age:"))
+
+		result, err := ageKeyring.Decrypt(value)
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", result)
+
+		_, err = aesKeyring.Decrypt(value)
+		assert.Equal(t, ErrKeyNotConfigured, err)
+	})
+
+	t.Run("plain value", func(t *testing.T) {
+		result, err := aesKeyring.Decrypt("secret")
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", result)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		_, err := aesKeyring.Decrypt("enc:aes:Zm9v")
+		assert.Equal(t, ErrDecrypt, err)
+
+		_, err = aesKeyring.Decrypt("enc:foo:Zm9v")
+		assert.ErrorIs(t, err, ErrDecrypt)
+	})
+}
+
+func TestBookmarkDecrypt(t *testing.T) {
+	k, err := NewKeyring(testKey())
+	require.NoError(t, err)
+
+	b, changed, err := Bookmark{
+		Password: "secret",
+		SSH:      &shared.SSHInfo{Host: "localhost", Password: "ssh-secret", KeyPassword: "key-secret"},
+	}.Encrypt(k)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, IsEncrypted(b.Password))
+	assert.True(t, IsEncrypted(b.SSH.Password))
+	assert.True(t, IsEncrypted(b.SSH.KeyPassword))
+
+	t.Run("without keyring", func(t *testing.T) {
+		_, err := b.ConvertToOptions()
+		assert.Equal(t, ErrKeyNotConfigured, err)
+
+		_, err = b.ResolveSSH()
+		assert.ErrorIs(t, err, ErrKeyNotConfigured)
+	})
+
+	t.Run("with keyring", func(t *testing.T) {
+		SetKeyring(k)
+		defer SetKeyring(nil)
+
+		opts, err := b.ConvertToOptions()
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", opts.Pass)
+
+		info, err := b.ResolveSSH()
+		assert.NoError(t, err)
+		assert.Equal(t, "ssh-secret", info.Password)
+		assert.Equal(t, "key-secret", info.KeyPassword)
+		assert.True(t, IsEncrypted(b.SSH.Password))
+	})
+
+	t.Run("already encrypted", func(t *testing.T) {
+		_, changed, err := b.Encrypt(k)
+		assert.NoError(t, err)
+		assert.False(t, changed)
+	})
+}
+
+func TestManagerEncrypt(t *testing.T) {
+	k, err := NewKeyring(testKey())
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plain.toml"), []byte(`# Production database
+host = "localhost"
+user = "postgres"
+password = "secret#1" # rotated monthly
+database = "mydatabase"
+
+[ssh]
+host = "bastion"
+user = "ubuntu"
+password = 'ssh-secret'
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "multiline.toml"), []byte(`password = """
+secret"""
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nopass.toml"), []byte(`url = "postgres://localhost/db"`), 0644))
+
+	manager := NewManager(dir)
+
+	changed, err := manager.Encrypt(k)
+	assert.EqualError(t, err, "unable to encrypt bookmark multiline: password values must be single line strings to be encrypted")
+	assert.Empty(t, changed)
+	require.NoError(t, os.Remove(filepath.Join(dir, "multiline.toml")))
+
+	changed, err = manager.Encrypt(k)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"plain"}, changed)
+
+	data, err := os.ReadFile(filepath.Join(dir, "plain.toml"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+	assert.Regexp(t, `(?s)^# Production database\nhost = "localhost"\nuser = "postgres"\npassword = "[^"]+" # rotated monthly\ndatabase = "mydatabase"\n`, string(data))
+
+	b, err := manager.Get("plain")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", b.Host)
+	assert.Equal(t, "mydatabase", b.Database)
+	assert.Equal(t, "bastion", b.SSH.Host)
+
+	SetKeyring(k)
+	defer SetKeyring(nil)
+
+	opts, err := b.ConvertToOptions()
+	assert.NoError(t, err)
+	assert.Equal(t, "secret#1", opts.Pass)
+
+	changed, err = manager.Encrypt(k)
+	assert.NoError(t, err)
+	assert.Empty(t, changed)
+}
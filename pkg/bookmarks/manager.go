@@ -5,13 +5,25 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/BurntSushi/toml"
 )
 
 type Manager struct {
-	dir string
+	dir   string
+	index *index
+}
+
+// index is an in-memory copy of the bookmarks directory
+type index struct {
+	bookmarks []Bookmark
+	errors    map[string]string
+	err       error
+	mu        sync.RWMutex
 }
 
 func NewManager(dir string) Manager {
@@ -53,11 +65,73 @@ func (m Manager) ListIDs() ([]string, error) {
 	return ids, nil
 }
 
+// EnableIndex keeps bookmarks in memory, the index must be reloaded once files change
+func (m *Manager) EnableIndex() {
+	m.index = &index{}
+	m.Reload()
+}
+
+// DisableIndex makes the manager read bookmark files on every call again
+func (m *Manager) DisableIndex() {
+	m.index = nil
+}
+
+// Reload re-reads the bookmarks directory into the index
+func (m Manager) Reload() {
+	if m.index == nil {
+		return
+	}
+
+	bookmarks, errs, err := m.scan()
+
+	m.index.mu.Lock()
+	defer m.index.mu.Unlock()
+
+	m.index.bookmarks = bookmarks
+	m.index.errors = errs
+	m.index.err = err
+}
+
+// Dir returns the bookmarks directory
+func (m Manager) Dir() string {
+	return m.dir
+}
+
+// Errors returns errors of invalid bookmark files, keyed by file name
+func (m Manager) Errors() (map[string]string, error) {
+	if m.index != nil {
+		m.index.mu.RLock()
+		defer m.index.mu.RUnlock()
+		return m.index.errors, m.index.err
+	}
+
+	_, errs, err := m.scan()
+	return errs, err
+}
+
 func (m Manager) list() ([]Bookmark, error) {
+	if m.index != nil {
+		m.index.mu.RLock()
+		defer m.index.mu.RUnlock()
+		return slices.Clone(m.index.bookmarks), m.index.err
+	}
+
+	result, errs, err := m.scan()
+	for name, msg := range errs {
+		// Do not fail if one of the bookmarks is invalid
+		fmt.Fprintf(os.Stderr, "[WARN] bookmark file %s is invalid: %s\n", name, msg)
+	}
+
+	return result, err
+}
+
+// scan reads all bookmark files, errors of invalid files are collected by file name
+func (m Manager) scan() ([]Bookmark, map[string]string, error) {
 	result := []Bookmark{}
+	errs := map[string]string{}
 
 	if m.dir == "" {
-		return result, nil
+		return result, errs, nil
 	}
 
 	info, err := os.Stat(m.dir)
@@ -65,17 +139,17 @@ func (m Manager) list() ([]Bookmark, error) {
 		// Do not fail if base dir does not exists: it's not created by default
 		if errors.Is(err, os.ErrNotExist) {
 			fmt.Fprintf(os.Stderr, "[WARN] bookmarks dir %s does not exist\n", m.dir)
-			return result, nil
+			return result, errs, nil
 		}
-		return nil, err
+		return nil, errs, err
 	}
 	if !info.IsDir() {
-		return nil, fmt.Errorf("path %s is not a directory", m.dir)
+		return nil, errs, fmt.Errorf("path %s is not a directory", m.dir)
 	}
 
 	dirEntries, err := os.ReadDir(m.dir)
 	if err != nil {
-		return nil, err
+		return nil, errs, err
 	}
 
 	for _, entry := range dirEntries {
@@ -86,15 +160,14 @@ func (m Manager) list() ([]Bookmark, error) {
 
 		bookmark, err := readBookmark(filepath.Join(m.dir, name))
 		if err != nil {
-			// Do not fail if one of the bookmarks is invalid
-			fmt.Fprintf(os.Stderr, "[WARN] bookmark file %s is invalid: %s\n", name, err)
+			errs[name] = err.Error()
 			continue
 		}
 
 		result = append(result, bookmark)
 	}
 
-	return result, nil
+	return result, errs, nil
 }
 
 func readBookmark(path string) (Bookmark, error) {
@@ -115,22 +188,16 @@ func readBookmark(path string) (Bookmark, error) {
 		return bookmark, err
 	}
 
-	_, err = toml.Decode(string(buff), &bookmark)
+	md, err := toml.Decode(string(buff), &bookmark)
+	if err == nil {
+		err = decodeKeyAliases(string(buff), md, &bookmark)
+	}
 
 	if bookmark.Port == 0 {
 		bookmark.Port = 5432
 	}
 
-	// List of all supported postgres modes
-	modes := []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
-	valid := false
-
-	for _, mode := range modes {
-		if bookmark.SSLMode == mode {
-			valid = true
-			break
-		}
-	}
+	valid := slices.Contains(sslModes, bookmark.SSLMode)
 
 	// Fall back to a default mode if mode is not set or invalid
 	// Typical typo: ssl mode set to "disabled"
@@ -146,7 +213,351 @@ func readBookmark(path string) (Bookmark, error) {
 	return bookmark, err
 }
 
+// keyAliases contains alternative spellings of the password source keys
+type keyAliases struct {
+	PasswordCommand string `toml:"passwordcommand"`
+	PasswordSecret  string `toml:"passwordsecret"`
+	SSH             *struct {
+		PasswordCommand    string `toml:"passwordcommand"`
+		PasswordSecret     string `toml:"passwordsecret"`
+		KeyPasswordCommand string `toml:"keypasswordcommand"`
+		KeyPasswordSecret  string `toml:"keypasswordsecret"`
+	} `toml:"ssh"`
+}
+
+// decodeKeyAliases applies aliased keys to the bookmark and returns an error
+// if the data contains keys that are not known
+func decodeKeyAliases(data string, md toml.MetaData, bookmark *Bookmark) error {
+	aliases := keyAliases{}
+	aliasesMd, err := toml.Decode(data, &aliases)
+	if err != nil {
+		return err
+	}
+
+	undecoded := map[string]bool{}
+	for _, key := range aliasesMd.Undecoded() {
+		undecoded[key.String()] = true
+	}
+
+	unknown := []string{}
+	for _, key := range md.Undecoded() {
+		if undecoded[key.String()] {
+			unknown = append(unknown, key.String())
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown bookmark keys: %s", strings.Join(unknown, ", "))
+	}
+
+	if bookmark.PasswordCommand == "" {
+		bookmark.PasswordCommand = aliases.PasswordCommand
+	}
+	if bookmark.PasswordSecret == "" {
+		bookmark.PasswordSecret = aliases.PasswordSecret
+	}
+	if bookmark.SSH != nil && aliases.SSH != nil {
+		if bookmark.SSH.PasswordCommand == "" {
+			bookmark.SSH.PasswordCommand = aliases.SSH.PasswordCommand
+		}
+		if bookmark.SSH.PasswordSecret == "" {
+			bookmark.SSH.PasswordSecret = aliases.SSH.PasswordSecret
+		}
+		if bookmark.SSH.KeyPasswordCommand == "" {
+			bookmark.SSH.KeyPasswordCommand = aliases.SSH.KeyPasswordCommand
+		}
+		if bookmark.SSH.KeyPasswordSecret == "" {
+			bookmark.SSH.KeyPasswordSecret = aliases.SSH.KeyPasswordSecret
+		}
+	}
+
+	return nil
+}
+
 func fileBasename(path string) string {
 	filename := filepath.Base(path)
 	return strings.Replace(filename, filepath.Ext(path), "", 1)
 }
+
+// Create writes a new bookmark file
+func (m Manager) Create(bookmark Bookmark) error {
+	if err := bookmark.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(m.path(bookmark.ID)); err == nil {
+		return ErrBookmarkExists
+	}
+
+	return m.write(bookmark)
+}
+
+// Update replaces an existing bookmark file, the bookmark is renamed when its id changes.
+// Passwords are written as provided, see Bookmark.KeepPasswords to keep the stored ones.
+func (m Manager) Update(id string, bookmark Bookmark) error {
+	if _, err := m.read(id); err != nil {
+		return err
+	}
+
+	if bookmark.ID == "" {
+		bookmark.ID = id
+	}
+	if err := bookmark.Validate(); err != nil {
+		return err
+	}
+
+	if bookmark.ID != id {
+		if _, err := os.Stat(m.path(bookmark.ID)); err == nil {
+			return ErrBookmarkExists
+		}
+	}
+
+	if err := m.write(bookmark); err != nil {
+		return err
+	}
+
+	if bookmark.ID != id {
+		if err := os.Remove(m.path(id)); err != nil {
+			return err
+		}
+		m.Reload()
+	}
+
+	return nil
+}
+
+// Delete removes the bookmark file
+func (m Manager) Delete(id string) error {
+	if !reBookmarkID.MatchString(id) {
+		return ErrBookmarkNotFound
+	}
+
+	err := os.Remove(m.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrBookmarkNotFound
+	}
+	if err == nil {
+		m.Reload()
+	}
+	return err
+}
+
+func (m Manager) path(id string) string {
+	return filepath.Join(m.dir, id+".toml")
+}
+
+// read reads the bookmark file directly, bypassing the index
+func (m Manager) read(id string) (Bookmark, error) {
+	if !reBookmarkID.MatchString(id) {
+		return Bookmark{}, ErrBookmarkNotFound
+	}
+	if _, err := os.Stat(m.path(id)); errors.Is(err, os.ErrNotExist) {
+		return Bookmark{}, ErrBookmarkNotFound
+	}
+	return readBookmark(m.path(id))
+}
+
+// write saves the bookmark file atomically, files are only readable by the owner
+// since they could contain passwords
+func (m Manager) write(bookmark Bookmark) error {
+	if m.dir == "" {
+		return errors.New("bookmarks directory is not set")
+	}
+	if err := os.MkdirAll(m.dir, 0700); err != nil {
+		return err
+	}
+
+	// New passwords are encrypted when the master key is configured
+	if keyring != nil {
+		var err error
+		if bookmark, _, err = bookmark.Encrypt(keyring); err != nil {
+			return err
+		}
+	}
+
+	data, err := encodeBookmark(bookmark)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFile(m.path(bookmark.ID), data); err != nil {
+		return err
+	}
+
+	m.Reload()
+	return nil
+}
+
+// writeFile replaces the file atomically
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".bookmark-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Encrypt encrypts plain passwords of all bookmark files in place.
+// Returns ids of the bookmarks that were changed.
+func (m Manager) Encrypt(k *Keyring) ([]string, error) {
+	bookmarks, errs, err := m.scan()
+	if err != nil {
+		return nil, err
+	}
+	for name, msg := range errs {
+		fmt.Fprintf(os.Stderr, "[WARN] bookmark file %s is invalid: %s\n", name, msg)
+	}
+
+	changed := []string{}
+	for _, bookmark := range bookmarks {
+		ok, err := encryptFile(m.path(bookmark.ID), k)
+		if err != nil {
+			return changed, fmt.Errorf("unable to encrypt bookmark %s: %w", bookmark.ID, err)
+		}
+		if ok {
+			changed = append(changed, bookmark.ID)
+		}
+	}
+
+	m.Reload()
+	return changed, nil
+}
+
+// encryptFile encrypts password values of the bookmark file in place, comments,
+// formatting and order of other fields are kept as is.
+func encryptFile(path string, k *Keyring) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	table := ""
+	lines := strings.Split(string(data), "\n")
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			name, _, _ := strings.Cut(strings.TrimPrefix(trimmed, "["), "]")
+			table = strings.ToLower(strings.Trim(strings.TrimSpace(name), `"'`))
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || !isPasswordKey(table, key) {
+			continue
+		}
+
+		prefix, str, suffix, ok := splitStringValue(value)
+		if !ok || str == "" || IsEncrypted(str) {
+			continue
+		}
+
+		encrypted, err := k.Encrypt(str)
+		if err != nil {
+			return false, err
+		}
+		lines[i] = key + "=" + prefix + strconv.Quote(encrypted) + suffix
+		changed = true
+	}
+
+	result := []byte(strings.Join(lines, "\n"))
+
+	// Multiline strings, inline tables or dotted keys are not rewritten
+	check := map[string]any{}
+	if _, err := toml.Decode(string(result), &check); err != nil {
+		return false, err
+	}
+	if hasPlainPasswords(check) {
+		return false, errors.New("password values must be single line strings to be encrypted")
+	}
+
+	if !changed {
+		return false, nil
+	}
+
+	return true, writeFile(path, result)
+}
+
+// isPasswordKey checks if the key holds a password in the given table, keys are case insensitive
+func isPasswordKey(table string, key string) bool {
+	key = strings.ToLower(strings.Trim(strings.TrimSpace(key), `"'`))
+
+	switch table {
+	case "":
+		return key == "password"
+	case "ssh":
+		return key == "password" || key == "keypassword"
+	}
+	return false
+}
+
+// splitStringValue parses a single line string value, returning the whitespace before
+// the value and anything after it, such as comments, so they could be preserved.
+func splitStringValue(value string) (string, string, string, bool) {
+	literal := strings.TrimSpace(value)
+	prefix := value[:len(value)-len(strings.TrimLeft(value, " \t"))]
+
+	// Comment markers could be a part of the string itself
+	for idx := strings.Index(literal, "#"); idx >= 0; {
+		if str, ok := decodeString(literal[:idx]); ok {
+			literal = strings.TrimSpace(literal[:idx])
+			return prefix, str, value[len(prefix)+len(literal):], true
+		}
+
+		next := strings.Index(literal[idx+1:], "#")
+		if next < 0 {
+			break
+		}
+		idx += next + 1
+	}
+
+	str, ok := decodeString(literal)
+	return prefix, str, value[len(prefix)+len(literal):], ok
+}
+
+func decodeString(literal string) (string, bool) {
+	data := map[string]any{}
+	if _, err := toml.Decode("value = "+literal, &data); err != nil {
+		return "", false
+	}
+
+	str, ok := data["value"].(string)
+	return str, ok
+}
+
+// hasPlainPasswords checks if any of password fields is not encrypted
+func hasPlainPasswords(data map[string]any) bool {
+	for key, val := range data {
+		switch v := val.(type) {
+		case string:
+			if isPasswordKey("", key) && v != "" && !IsEncrypted(v) {
+				return true
+			}
+		case map[string]any:
+			if !strings.EqualFold(key, "ssh") {
+				continue
+			}
+			for name, val := range v {
+				str, ok := val.(string)
+				if ok && isPasswordKey("ssh", name) && str != "" && !IsEncrypted(str) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
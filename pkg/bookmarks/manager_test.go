@@ -1,9 +1,14 @@
 package bookmarks
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sosedoff/pgweb/pkg/shared"
 )
 
 func TestManagerList(t *testing.T) {
@@ -52,6 +57,120 @@ func TestManagerGet(t *testing.T) {
 	assert.Nil(t, b)
 }
 
+func TestManagerErrors(t *testing.T) {
+	errs, err := NewManager("../../data").Errors()
+	assert.NoError(t, err)
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs["invalid.toml"], "toml: line 1")
+}
+
+func TestManagerIndex(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("foo.toml", `host = "localhost"`)
+
+	manager := NewManager(dir)
+	manager.EnableIndex()
+
+	ids, err := manager.ListIDs()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, ids)
+
+	// Index is not updated until reloaded
+	write("bar.toml", `host = "localhost"`)
+	write("invalid.toml", `host = `)
+
+	ids, err = manager.ListIDs()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, ids)
+
+	manager.Reload()
+
+	ids, err = manager.ListIDs()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bar", "foo"}, ids)
+
+	errs, err := manager.Errors()
+	assert.NoError(t, err)
+	assert.Contains(t, errs, "invalid.toml")
+}
+
+func TestManagerWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "bookmarks")
+	manager := NewManager(dir)
+
+	maxCost, maxRows := float64(1000), int64(0)
+
+	bookmark := Bookmark{
+		ID:           "prod",
+		Host:         "db.example.com",
+		Port:         5433,
+		User:         "admin",
+		Password:     "secret",
+		Database:     "app",
+		SSLMode:      "require",
+		ReadOnly:     true,
+		MaxQueryCost: &maxCost,
+		MaxQueryRows: &maxRows, // Zero disables the global limit
+		SSH:          &shared.SSHInfo{Host: "bastion", Port: "22", User: "deploy", Password: "ssh-secret"},
+	}
+
+	t.Run("validate", func(t *testing.T) {
+		assert.Equal(t, ErrInvalidBookmarkID, manager.Create(Bookmark{ID: "../foo", Host: "localhost"}))
+		assert.EqualError(t, manager.Create(Bookmark{ID: "foo"}), "bookmark url or host must be set")
+		assert.EqualError(t, manager.Create(Bookmark{ID: "foo", Host: "localhost", SSLMode: "disabled"}), `invalid bookmark ssl mode: "disabled"`)
+
+		negative := int64(-1)
+		assert.EqualError(t, manager.Create(Bookmark{ID: "foo", Host: "localhost", MaxQueryRows: &negative}), "bookmark query cost limits must not be negative")
+	})
+
+	t.Run("create", func(t *testing.T) {
+		require.NoError(t, manager.Create(bookmark))
+		assert.Equal(t, ErrBookmarkExists, manager.Create(bookmark))
+
+		info, err := os.Stat(filepath.Join(dir, "prod.toml"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		saved, err := manager.Get("prod")
+		require.NoError(t, err)
+		assert.Equal(t, bookmark, *saved)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		update := Bookmark{
+			ID:       "production",
+			Host:     "db2.example.com",
+			User:     "admin",
+			Database: "app",
+			SSH:      &shared.SSHInfo{Host: "bastion", User: "deploy"},
+		}
+		require.NoError(t, manager.Update("prod", update))
+		assert.Equal(t, ErrBookmarkNotFound, manager.Update("prod", update))
+
+		ids, err := manager.ListIDs()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"production"}, ids)
+
+		// Passwords are written as provided
+		saved, err := manager.Get("production")
+		require.NoError(t, err)
+		assert.Equal(t, "db2.example.com", saved.Host)
+		assert.Empty(t, saved.Password)
+		assert.Empty(t, saved.SSH.Password)
+		assert.Equal(t, 5432, saved.Port)
+		assert.Nil(t, saved.MaxQueryCost)
+		assert.Nil(t, saved.MaxQueryRows)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, manager.Delete("production"))
+		assert.Equal(t, ErrBookmarkNotFound, manager.Delete("production"))
+	})
+}
+
 func Test_fileBasename(t *testing.T) {
 	assert.Equal(t, "filename", fileBasename("filename.toml"))
 	assert.Equal(t, "filename", fileBasename("path/filename.toml"))
@@ -0,0 +1,212 @@
+package bookmarks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sosedoff/pgweb/pkg/shared"
+)
+
+// Maximum time to wait for password commands and secret providers
+const secretTimeout = 10 * time.Second
+
+var (
+	ErrUnknownSecretProvider = errors.New("unknown secret provider")
+
+	secretProviders = map[string]SecretProvider{
+		"file":  FileProvider{},
+		"vault": &VaultProvider{},
+	}
+	secretProvidersMu sync.RWMutex
+)
+
+// SecretProvider reads secrets referenced by bookmarks, ie "vault:secret/data/db#password"
+type SecretProvider interface {
+	Secret(ref string) (string, error)
+}
+
+// RegisterSecretProvider makes the provider available for secret references with the given scheme
+func RegisterSecretProvider(scheme string, provider SecretProvider) {
+	secretProvidersMu.Lock()
+	defer secretProvidersMu.Unlock()
+
+	secretProviders[scheme] = provider
+}
+
+// ReadSecret returns the secret value for the "<scheme>:<ref>" reference
+func ReadSecret(ref string) (string, error) {
+	scheme, path, ok := strings.Cut(ref, ":")
+	if !ok {
+		return "", fmt.Errorf("invalid secret reference: %q", ref)
+	}
+
+	secretProvidersMu.RLock()
+	provider := secretProviders[scheme]
+	secretProvidersMu.RUnlock()
+
+	if provider == nil {
+		return "", fmt.Errorf("%w: %q", ErrUnknownSecretProvider, scheme)
+	}
+
+	return provider.Secret(path)
+}
+
+// FileProvider reads secrets from local files, ie mounted docker or kubernetes secrets
+type FileProvider struct{}
+
+// Secret returns the file content without trailing newline
+func (FileProvider) Secret(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// VaultProvider reads secrets from HashiCorp Vault compatible HTTP API.
+// Reference format is "<path>#<key>", key defaults to "password".
+// Address, token and namespace fall back to VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE env vars.
+type VaultProvider struct {
+	Addr      string
+	Token     string
+	Namespace string
+	Client    *http.Client
+}
+
+// Secret returns the key of the secret, both KV v1 and v2 engines are supported
+func (p *VaultProvider) Secret(ref string) (string, error) {
+	path, key, _ := strings.Cut(ref, "#")
+	if key == "" {
+		key = "password"
+	}
+
+	addr := p.Addr
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	if addr == "" {
+		return "", errors.New("vault address is not configured")
+	}
+
+	token := p.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+
+	namespace := p.Namespace
+	if namespace == "" {
+		namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: secretTimeout}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault secret %q request failed with status %d", path, resp.StatusCode)
+	}
+
+	body := struct {
+		Data map[string]any `json:"data"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+
+	// KV v2 engine nests secret data along with metadata
+	data := body.Data
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+
+	val, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %q does not have %q key", path, key)
+	}
+
+	return val, nil
+}
+
+// runPasswordCommand executes the command with the system shell and returns its output
+func runPasswordCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stderr = os.Stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("password command failed: %w", err)
+	}
+
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
+
+// resolvePassword returns the password value, the password command output or the secret.
+// Encrypted values are decrypted with the configured keyring.
+func resolvePassword(value string, command string, secret string) (string, error) {
+	switch {
+	case value != "":
+		return decrypt(value)
+	case command != "":
+		return runPasswordCommand(command)
+	case secret != "":
+		return ReadSecret(secret)
+	}
+	return "", nil
+}
+
+// ResolveSSH returns a copy of ssh tunnel config with decrypted passwords
+// and secrets of password commands and providers
+func (b Bookmark) ResolveSSH() (*shared.SSHInfo, error) {
+	if b.SSH == nil {
+		return nil, nil
+	}
+
+	info := *b.SSH
+
+	var err error
+	if info.Password, err = resolvePassword(info.Password, info.PasswordCommand, info.PasswordSecret); err != nil {
+		return nil, fmt.Errorf("unable to resolve ssh password: %w", err)
+	}
+	if info.KeyPassword, err = resolvePassword(info.KeyPassword, info.KeyPasswordCommand, info.KeyPasswordSecret); err != nil {
+		return nil, fmt.Errorf("unable to resolve ssh key password: %w", err)
+	}
+
+	return &info, nil
+}
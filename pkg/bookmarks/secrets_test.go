@@ -0,0 +1,209 @@
+package bookmarks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sosedoff/pgweb/pkg/shared"
+)
+
+type testProvider map[string]string
+
+func (p testProvider) Secret(ref string) (string, error) {
+	return p[ref], nil
+}
+
+func TestReadSecret(t *testing.T) {
+	t.Run("invalid reference", func(t *testing.T) {
+		_, err := ReadSecret("foo")
+		assert.EqualError(t, err, `invalid secret reference: "foo"`)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		_, err := ReadSecret("foo:bar")
+		assert.ErrorIs(t, err, ErrUnknownSecretProvider)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "password")
+		require.NoError(t, os.WriteFile(path, []byte("secret\n"), 0600))
+
+		val, err := ReadSecret("file:" + path)
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", val)
+
+		_, err = ReadSecret("file:" + path + ".missing")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("custom provider", func(t *testing.T) {
+		RegisterSecretProvider("test", testProvider{"db": "secret"})
+		defer RegisterSecretProvider("test", nil)
+
+		val, err := ReadSecret("test:db")
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", val)
+	})
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/secret/data/db":
+			w.Write([]byte(`{"data":{"data":{"password":"v2-secret","user":"admin"},"metadata":{"version":1}}}`))
+		case "/v1/kv/db":
+			w.Write([]byte(`{"data":{"password":"v1-secret"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider := &VaultProvider{Addr: server.URL, Token: "token"}
+
+	t.Run("kv v2", func(t *testing.T) {
+		val, err := provider.Secret("secret/data/db")
+		assert.NoError(t, err)
+		assert.Equal(t, "v2-secret", val)
+
+		val, err = provider.Secret("secret/data/db#user")
+		assert.NoError(t, err)
+		assert.Equal(t, "admin", val)
+	})
+
+	t.Run("kv v1", func(t *testing.T) {
+		val, err := provider.Secret("kv/db")
+		assert.NoError(t, err)
+		assert.Equal(t, "v1-secret", val)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		_, err := provider.Secret("kv/db#foo")
+		assert.EqualError(t, err, `vault secret "kv/db" does not have "foo" key`)
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		_, err := provider.Secret("kv/foo")
+		assert.EqualError(t, err, `vault secret "kv/foo" request failed with status 404`)
+	})
+
+	t.Run("env vars", func(t *testing.T) {
+		t.Setenv("VAULT_ADDR", server.URL)
+		t.Setenv("VAULT_TOKEN", "token")
+
+		val, err := ReadSecret("vault:kv/db")
+		assert.NoError(t, err)
+		assert.Equal(t, "v1-secret", val)
+	})
+}
+
+func TestBookmarkPasswordSources(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("password command tests require sh")
+	}
+
+	RegisterSecretProvider("test", testProvider{"db": "provider-secret", "ssh": "ssh-secret"})
+	defer RegisterSecretProvider("test", nil)
+
+	t.Run("password command", func(t *testing.T) {
+		opts, err := Bookmark{PasswordCommand: "echo command-secret"}.ConvertToOptions()
+		assert.NoError(t, err)
+		assert.Equal(t, "command-secret", opts.Pass)
+
+		_, err = Bookmark{PasswordCommand: "exit 1"}.ConvertToOptions()
+		assert.ErrorContains(t, err, "password command failed")
+	})
+
+	t.Run("secret provider", func(t *testing.T) {
+		opts, err := Bookmark{PasswordSecret: "test:db"}.ConvertToOptions()
+		assert.NoError(t, err)
+		assert.Equal(t, "provider-secret", opts.Pass)
+	})
+
+	t.Run("password takes precedence", func(t *testing.T) {
+		opts, err := Bookmark{Password: "password", PasswordCommand: "exit 1"}.ConvertToOptions()
+		assert.NoError(t, err)
+		assert.Equal(t, "password", opts.Pass)
+	})
+
+	t.Run("ssh", func(t *testing.T) {
+		b := Bookmark{
+			SSH: &shared.SSHInfo{
+				Host:               "localhost",
+				PasswordSecret:     "test:ssh",
+				KeyPasswordCommand: "echo key-secret",
+			},
+		}
+
+		info, err := b.ResolveSSH()
+		assert.NoError(t, err)
+		assert.Equal(t, "ssh-secret", info.Password)
+		assert.Equal(t, "key-secret", info.KeyPassword)
+		assert.Empty(t, b.SSH.Password)
+	})
+}
+
+func TestReadBookmarkPasswordSources(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secrets.toml"), []byte(`
+host = "localhost"
+password_command = "pass show db"
+
+[ssh]
+host = "bastion"
+password_secret = "vault:secret/data/ssh"
+keypassword_command = "pass show key"
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "aliases.toml"), []byte(`
+host = "localhost"
+passwordcommand = "pass show db"
+
+[SSH]
+host = "bastion"
+passwordsecret = "vault:secret/data/ssh"
+keypasswordcommand = "pass show key"
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "unknown.toml"), []byte(`
+host = "localhost"
+password_cmd = "pass show db"
+
+[ssh]
+host = "bastion"
+key_password = "secret"
+`), 0600))
+
+	manager := NewManager(dir)
+
+	for _, id := range []string{"secrets", "aliases"} {
+		t.Run(id, func(t *testing.T) {
+			b, err := manager.Get(id)
+			require.NoError(t, err)
+			assert.Equal(t, "pass show db", b.PasswordCommand)
+			assert.Equal(t, "vault:secret/data/ssh", b.SSH.PasswordSecret)
+			assert.Equal(t, "pass show key", b.SSH.KeyPasswordCommand)
+
+			data, err := encodeBookmark(*b)
+			require.NoError(t, err)
+			assert.Contains(t, string(data), `password_command = "pass show db"`)
+			assert.Contains(t, string(data), `keypassword_command = "pass show key"`)
+		})
+	}
+
+	t.Run("unknown keys", func(t *testing.T) {
+		errs, err := manager.Errors()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"unknown.toml": "unknown bookmark keys: password_cmd, ssh.key_password"}, errs)
+	})
+}
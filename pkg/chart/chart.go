@@ -0,0 +1,99 @@
+package chart
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	Line = "line"
+	Bar  = "bar"
+	Pie  = "pie"
+
+	AggregateNone  = "none"
+	AggregateSum   = "sum"
+	AggregateAvg   = "avg"
+	AggregateMin   = "min"
+	AggregateMax   = "max"
+	AggregateCount = "count"
+)
+
+var (
+	types      = []string{Line, Bar, Pie}
+	aggregates = []string{AggregateNone, AggregateSum, AggregateAvg, AggregateMin, AggregateMax, AggregateCount}
+)
+
+type (
+	// Definition describes how the result columns are plotted
+	Definition struct {
+		Type      string   `json:"type"`             // Chart type: line, bar or pie
+		X         string   `json:"x"`                // Column with labels
+		Y         []string `json:"y"`                // Columns with values
+		Series    string   `json:"series,omitempty"` // Column to split values into datasets
+		Aggregate string   `json:"aggregate"`        // Aggregation of values with the same label
+	}
+
+	// Data contains the normalized chart payload
+	Data struct {
+		Type     string    `json:"type"`
+		X        string    `json:"x"`
+		Labels   []string  `json:"labels"`
+		Datasets []Dataset `json:"datasets"`
+	}
+
+	// Dataset contains values of the dataset for each label, nil for missing values
+	Dataset struct {
+		Label string     `json:"label"`
+		Data  []*float64 `json:"data"`
+	}
+)
+
+// NewDefinition returns a validated chart definition with default aggregation.
+// Y columns are given as a comma-separated list.
+func NewDefinition(chartType, x, y, series, aggregate string) (*Definition, error) {
+	def := &Definition{
+		Type:      strings.TrimSpace(chartType),
+		X:         strings.TrimSpace(x),
+		Y:         []string{},
+		Series:    strings.TrimSpace(series),
+		Aggregate: strings.TrimSpace(aggregate),
+	}
+
+	for _, name := range strings.Split(y, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			def.Y = append(def.Y, name)
+		}
+	}
+
+	if def.Aggregate == "" {
+		def.Aggregate = AggregateNone
+	}
+
+	return def, def.Validate()
+}
+
+// Validate checks the chart definition without the result
+func (def Definition) Validate() error {
+	if !slices.Contains(types, def.Type) {
+		return fmt.Errorf("invalid chart type: %q", def.Type)
+	}
+	if !slices.Contains(aggregates, def.Aggregate) {
+		return fmt.Errorf("invalid chart aggregate: %q", def.Aggregate)
+	}
+	if def.X == "" {
+		return errors.New("chart x column must be set")
+	}
+	if len(def.Y) == 0 && def.Aggregate != AggregateCount {
+		return errors.New("chart y columns must be set")
+	}
+	if def.Type == Pie && (len(def.Y) > 1 || def.Series != "") {
+		return errors.New("pie chart supports a single y column without series")
+	}
+	if def.Series != "" && len(def.Y) > 1 {
+		return errors.New("chart series supports a single y column")
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package chart
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDefinition(t *testing.T) {
+	examples := []struct {
+		name      string
+		chartType string
+		x         string
+		y         string
+		series    string
+		aggregate string
+		err       string
+	}{
+		{name: "valid", chartType: "line", x: "day", y: "total, count"},
+		{name: "invalid type", chartType: "area", x: "day", y: "total", err: `invalid chart type: "area"`},
+		{name: "invalid aggregate", chartType: "bar", x: "day", y: "total", aggregate: "median", err: `invalid chart aggregate: "median"`},
+		{name: "missing x", chartType: "bar", y: "total", err: "chart x column must be set"},
+		{name: "missing y", chartType: "bar", x: "day", err: "chart y columns must be set"},
+		{name: "count without y", chartType: "bar", x: "day", aggregate: "count"},
+		{name: "pie with multiple y", chartType: "pie", x: "day", y: "a,b", err: "pie chart supports a single y column without series"},
+		{name: "series with multiple y", chartType: "line", x: "day", y: "a,b", series: "kind", err: "chart series supports a single y column"},
+	}
+
+	for _, ex := range examples {
+		t.Run(ex.name, func(t *testing.T) {
+			def, err := NewDefinition(ex.chartType, ex.x, ex.y, ex.series, ex.aggregate)
+			if ex.err != "" {
+				assert.EqualError(t, err, ex.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEmpty(t, def.Aggregate)
+		})
+	}
+}
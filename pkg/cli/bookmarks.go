@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/sosedoff/pgweb/pkg/bookmarks"
+	"github.com/sosedoff/pgweb/pkg/command"
+)
+
+const bookmarksUsage = `Usage:
+  pgweb bookmarks encrypt [--bookmarks-dir=DIR] [--bookmarks-key-file=FILE] [--bookmarks-identity=FILE]
+
+Encrypts plain passwords of all bookmark files with the master key.
+Master key is read from the PGWEB_BOOKMARKS_KEY env var, key file or age identity file.`
+
+// runBookmarksCommand runs the bookmarks management command
+func runBookmarksCommand(args []string) {
+	if len(args) == 0 || args[0] != "encrypt" {
+		fmt.Println(bookmarksUsage)
+		os.Exit(1)
+	}
+
+	opts, err := command.ParseOptions(args[1:])
+	if err != nil {
+		os.Exit(1)
+	}
+
+	keyring, err := loadBookmarksKeyring(opts)
+	if err != nil {
+		exitWithMessage(err.Error())
+	}
+	if keyring == nil {
+		exitWithMessage("master key is not configured")
+	}
+
+	manager := bookmarks.NewManager(opts.BookmarksDir)
+
+	changed, err := manager.Encrypt(keyring)
+	for _, id := range changed {
+		fmt.Printf("Encrypted bookmark %s\n", id)
+	}
+	if err != nil {
+		exitWithMessage(err.Error())
+	}
+	if len(changed) == 0 {
+		fmt.Println("No bookmarks with plain passwords found")
+	}
+
+	// Passwords of connection strings could not be encrypted
+	list, err := manager.List()
+	if err != nil {
+		exitWithMessage(err.Error())
+	}
+	for _, bookmark := range list {
+		if u, err := url.Parse(bookmark.URL); err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				fmt.Printf("[WARN] bookmark %s has a password in the connection url, move it into the password field\n", bookmark.ID)
+			}
+		}
+	}
+}
+
+// loadBookmarksKeyring returns the keyring used for bookmark passwords, nil if not configured
+func loadBookmarksKeyring(opts command.Options) (*bookmarks.Keyring, error) {
+	keyring, err := bookmarks.LoadKeyring(os.Getenv("PGWEB_BOOKMARKS_KEY"), opts.BookmarksKeyFile, opts.BookmarksIdentity)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load bookmarks master key: %w", err)
+	}
+	return keyring, nil
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
@@ -19,9 +20,13 @@ import (
 	"github.com/sosedoff/pgweb/pkg/client"
 	"github.com/sosedoff/pgweb/pkg/command"
 	"github.com/sosedoff/pgweb/pkg/connection"
+	"github.com/sosedoff/pgweb/pkg/history"
 	"github.com/sosedoff/pgweb/pkg/metrics"
+	"github.com/sosedoff/pgweb/pkg/parser"
 	"github.com/sosedoff/pgweb/pkg/queries"
+	"github.com/sosedoff/pgweb/pkg/scheduler"
 	"github.com/sosedoff/pgweb/pkg/util"
+	"github.com/sosedoff/pgweb/pkg/watcher"
 )
 
 var (
@@ -134,21 +139,66 @@ func initOptions() {
 		fmt.Println(readonlyWarning)
 	}
 
+	if options.ReadOnlyDenyFunctions != "" {
+		parser.SetDeniedFunctions(strings.Split(options.ReadOnlyDenyFunctions, ","))
+	}
+
 	if options.BinaryCodec != "" {
 		if err := client.SetBinaryCodec(options.BinaryCodec); err != nil {
 			exitWithMessage(err.Error())
 		}
 	}
 
+	configureBookmarks()
 	configureLocalQueryStore()
+	configureHistoryStore()
 	printVersion()
 }
 
+func configureHistoryStore() {
+	switch options.HistoryStore {
+	case "", "memory":
+		return
+	case "file":
+		if options.HistoryFile == "" {
+			exitWithMessage("--history-file flag must be set")
+		}
+
+		store, err := history.NewFileStore(options.HistoryFile, int(options.HistoryLimit))
+		if err != nil {
+			exitWithMessage(fmt.Sprintf("unable to open query history file: %v", err))
+		}
+
+		logger.Debugf("using query history file %q", options.HistoryFile)
+		client.SetDefaultHistoryStore(store)
+	default:
+		exitWithMessage(fmt.Sprintf("invalid history store: %q", options.HistoryStore))
+	}
+}
+
+func configureBookmarks() {
+	keyring, err := loadBookmarksKeyring(options)
+	if err != nil {
+		exitWithMessage(err.Error())
+	}
+	bookmarks.SetKeyring(keyring)
+
+	manager := bookmarks.NewManager(options.BookmarksDir)
+	api.BookmarkManager = &manager
+}
+
 func configureLocalQueryStore() {
 	if options.Sessions || options.QueriesDir == "" {
 		return
 	}
 
+	// Saved queries are written into the local queries directory
+	if options.SavedQueries {
+		if err := os.MkdirAll(options.QueriesDir, 0755); err != nil {
+			logger.Debugf("unable to create local queries directory %q: %v", options.QueriesDir, err)
+		}
+	}
+
 	stat, err := os.Stat(options.QueriesDir)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -164,7 +214,108 @@ func configureLocalQueryStore() {
 		return
 	}
 
-	api.QueryStore = queries.NewStore(options.QueriesDir)
+	if !options.QueriesGit {
+		api.QueryStore = queries.NewStore(options.QueriesDir)
+		return
+	}
+
+	store, err := queries.NewGitStore(options.QueriesDir, options.QueriesGitRef)
+	if err != nil {
+		exitWithMessage(fmt.Sprintf("unable to read local queries from git: %v", err))
+	}
+	api.QueryStore = store
+}
+
+// watchLocalQueries reloads scheduled queries once the queries git repository changes
+func watchLocalQueries() {
+	interval := time.Duration(options.QueriesGitPoll) * time.Second
+
+	api.QueryStore.Watch(interval, func() {
+		logger.WithField("dir", options.QueriesDir).Info("local queries repository changed")
+		reloadLocalQueries()
+	})
+}
+
+// startConfigWatchers keeps bookmarks and local queries in memory and reloads them
+// once files change. Files are read on every request if directory could not be watched.
+func startConfigWatchers() {
+	watchBookmarks(api.BookmarkManager)
+
+	if api.QueryStore == nil {
+		return
+	}
+
+	// Queries read from the git revision are reloaded by the repository watcher
+	if api.QueryStore.IsReadOnly() || watchDir(options.QueriesDir, reloadLocalQueries) {
+		api.QueryStore.EnableIndex()
+	}
+}
+
+// watchBookmarks keeps bookmarks in memory if the bookmarks directory could be watched
+func watchBookmarks(manager *bookmarks.Manager) bool {
+	manager.EnableIndex()
+
+	// Reload must be called through the pointer, a method value would copy the manager
+	// before the index is set and every reload would be a no-op
+	if !watchDir(manager.Dir(), func() { manager.Reload() }) {
+		manager.DisableIndex()
+		return false
+	}
+
+	return true
+}
+
+func watchDir(dir string, onChange func()) bool {
+	_, err := watcher.New(dir, watcher.DefaultDelay, func() {
+		logger.WithField("dir", dir).Debug("directory changed, reloading files")
+		onChange()
+	}, func(err error) {
+		logger.WithError(err).WithField("dir", dir).Warn("directory watcher error")
+	})
+	if err != nil {
+		logger.WithError(err).WithField("dir", dir).Debug("unable to watch directory, files are read on every request")
+		return false
+	}
+
+	return true
+}
+
+func reloadLocalQueries() {
+	api.QueryStore.Reload()
+
+	if api.Scheduler != nil {
+		if err := api.Scheduler.Reload(); err != nil {
+			logger.WithError(err).Error("unable to reload scheduled queries")
+		}
+	}
+}
+
+func startScheduler() {
+	if api.QueryStore == nil {
+		logger.Warn("local queries are disabled, scheduler will not start")
+		return
+	}
+
+	snapshots := scheduler.NewSnapshotStore(options.SnapshotsDir, int(options.SnapshotsLimit))
+	snapshots.SetRowLimit(int(options.SnapshotsRowLimit))
+	api.Scheduler = scheduler.New(api.QueryStore, *api.BookmarkManager, snapshots, logger)
+
+	if options.AlertWebhook != "" {
+		webhook, err := scheduler.NewWebhook(options.AlertWebhook, options.AlertWebhookFormat)
+		if err != nil {
+			exitWithMessage(err.Error())
+		}
+
+		alerter, err := scheduler.NewAlerter(webhook, filepath.Join(options.SnapshotsDir, "alerts.json"))
+		if err != nil {
+			exitWithMessage(err.Error())
+		}
+		api.Scheduler.SetAlerter(alerter)
+	}
+
+	if err := api.Scheduler.Start(); err != nil {
+		exitWithMessage(fmt.Sprintf("unable to start scheduler: %v", err))
+	}
 }
 
 func configureLogger(opts command.Options) error {
@@ -295,6 +446,11 @@ func testClient(cl *client.Client, retryCount int, retryDelay time.Duration) (ab
 }
 
 func Run() {
+	if len(os.Args) > 1 && os.Args[1] == "bookmarks" {
+		runBookmarksCommand(os.Args[2:])
+		return
+	}
+
 	initOptions()
 	initClient()
 
@@ -321,6 +477,26 @@ func Run() {
 		}
 	}
 
+	// Start asynchronous query results cleanup worker
+	api.Jobs = api.NewJobManager(logger)
+	api.Jobs.SetTTL(time.Minute * time.Duration(options.JobTTL))
+	go api.Jobs.RunPeriodicCleanup()
+
+	// Start bookmarks and local queries directory watchers
+	if !options.DisableConfigWatch {
+		startConfigWatchers()
+	}
+
+	// Start scheduled local queries worker
+	if options.Scheduler {
+		startScheduler()
+	}
+
+	// Start local queries git repository watcher
+	if api.QueryStore != nil && options.QueriesGit && options.QueriesGitPoll > 0 {
+		go watchLocalQueries()
+	}
+
 	// Start a separate metrics http server. If metrics addr is not provided, we
 	// add the metrics endpoint in the existing application server (see api.go).
 	if options.MetricsEnabled && options.MetricsAddr != "" {
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sosedoff/pgweb/pkg/bookmarks"
+)
+
+func TestWatchBookmarks(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo.toml"), []byte(`host = "foo"`), 0644))
+
+	manager := bookmarks.NewManager(dir)
+	require.True(t, watchBookmarks(&manager))
+
+	ids, err := manager.ListIDs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, ids)
+
+	// Files changed after the watcher is started are picked up by the index
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bar.toml"), []byte(`host = "bar"`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo.toml"), []byte(`host = "updated"`), 0644))
+
+	assert.Eventually(t, func() bool {
+		bookmark, err := manager.Get("foo")
+		if err != nil || bookmark.Host != "updated" {
+			return false
+		}
+		ids, err := manager.ListIDs()
+		return err == nil && len(ids) == 2
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestWatchBookmarksMissingDir(t *testing.T) {
+	manager := bookmarks.NewManager(filepath.Join(t.TempDir(), "missing"))
+	assert.False(t, watchBookmarks(&manager))
+
+	// Files are read on every call if the directory could not be watched
+	ids, err := manager.ListIDs()
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
@@ -0,0 +1,191 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sosedoff/pgweb/pkg/chart"
+)
+
+var errChartTruncated = errors.New("chart requires the full result, the result is truncated by the row limit")
+
+// chartValue accumulates values with the same label
+type chartValue struct {
+	sum   float64
+	min   float64
+	max   float64
+	count int
+}
+
+// BuildChart validates the chart definition against the result columns
+// and returns the chart data. Truncated results are rejected.
+func (res *Result) BuildChart(def chart.Definition) (*chart.Data, error) {
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	if res.Stats != nil && res.Stats.Truncated {
+		return nil, errChartTruncated
+	}
+
+	columns := map[string]int{}
+	for i, name := range res.Columns {
+		columns[name] = i
+	}
+
+	for _, name := range append([]string{def.X, def.Series}, def.Y...) {
+		if _, ok := columns[name]; name != "" && !ok {
+			return nil, fmt.Errorf("chart column %q is not found in the result", name)
+		}
+	}
+
+	// Count aggregation does not require values
+	yColumns := def.Y
+	if len(yColumns) == 0 {
+		yColumns = []string{chart.AggregateCount}
+	}
+
+	labels := []string{}
+	labelIndex := map[string]int{}
+	datasetNames := []string{}
+	values := map[string]map[string]*chartValue{}
+
+	for _, row := range res.Rows {
+		label := chartLabel(row[columns[def.X]])
+		if _, ok := labelIndex[label]; !ok {
+			labelIndex[label] = len(labels)
+			labels = append(labels, label)
+		}
+
+		for _, yName := range yColumns {
+			dataset := yName
+			if def.Series != "" {
+				dataset = chartLabel(row[columns[def.Series]])
+			}
+
+			var value *float64
+			if yName != chart.AggregateCount || len(def.Y) > 0 {
+				num, err := chartNumber(row[columns[yName]])
+				if err != nil {
+					return nil, fmt.Errorf("chart column %q %w", yName, err)
+				}
+				value = num
+			}
+
+			if values[dataset] == nil {
+				values[dataset] = map[string]*chartValue{}
+				datasetNames = append(datasetNames, dataset)
+			}
+
+			current := values[dataset][label]
+			if current != nil && def.Aggregate == chart.AggregateNone {
+				return nil, fmt.Errorf("chart label %q has multiple values, aggregate must be set", label)
+			}
+			if current == nil {
+				current = &chartValue{}
+				values[dataset][label] = current
+			}
+			current.add(value, def.Aggregate == chart.AggregateCount)
+		}
+	}
+
+	data := &chart.Data{
+		Type:     def.Type,
+		X:        def.X,
+		Labels:   labels,
+		Datasets: make([]chart.Dataset, 0, len(datasetNames)),
+	}
+
+	for _, name := range datasetNames {
+		dataset := chart.Dataset{
+			Label: name,
+			Data:  make([]*float64, len(labels)),
+		}
+		for label, value := range values[name] {
+			dataset.Data[labelIndex[label]] = value.result(def.Aggregate)
+		}
+		data.Datasets = append(data.Datasets, dataset)
+	}
+
+	return data, nil
+}
+
+func (v *chartValue) add(value *float64, countNulls bool) {
+	if value == nil {
+		if countNulls {
+			v.count++
+		}
+		return
+	}
+
+	if v.count == 0 || *value < v.min {
+		v.min = *value
+	}
+	if v.count == 0 || *value > v.max {
+		v.max = *value
+	}
+	v.sum += *value
+	v.count++
+}
+
+func (v *chartValue) result(aggregate string) *float64 {
+	// Labels without any values are rendered as gaps
+	if v.count == 0 {
+		return nil
+	}
+
+	value := v.sum
+	switch aggregate {
+	case chart.AggregateCount:
+		value = float64(v.count)
+	case chart.AggregateAvg:
+		value = v.sum / float64(v.count)
+	case chart.AggregateMin:
+		value = v.min
+	case chart.AggregateMax:
+		value = v.max
+	}
+
+	return &value
+}
+
+func chartLabel(value interface{}) string {
+	switch val := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return val.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func chartNumber(value interface{}) (*float64, error) {
+	var num float64
+
+	switch val := value.(type) {
+	case nil:
+		return nil, nil
+	case int:
+		num = float64(val)
+	case int64:
+		num = float64(val)
+	case float64:
+		num = val
+	case bool:
+		if val {
+			num = 1
+		}
+	case string:
+		parsed, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("contains non-numeric value: %q", val)
+		}
+		num = parsed
+	default:
+		return nil, fmt.Errorf("contains non-numeric value: %v", val)
+	}
+
+	return &num, nil
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sosedoff/pgweb/pkg/chart"
+)
+
+func TestBuildChart(t *testing.T) {
+	day1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	result := &Result{
+		Columns: []string{"day", "kind", "total", "price"},
+		Rows: []Row{
+			{day1, "book", int64(2), "10.5"},
+			{day1, "ebook", int64(3), nil},
+			{day2, "book", int64(5), "20"},
+		},
+	}
+
+	val := func(v float64) *float64 { return &v }
+
+	t.Run("unknown column", func(t *testing.T) {
+		_, err := result.BuildChart(chart.Definition{Type: chart.Line, X: "day", Y: []string{"foo"}, Aggregate: chart.AggregateNone})
+		assert.EqualError(t, err, `chart column "foo" is not found in the result`)
+	})
+
+	t.Run("non-numeric column", func(t *testing.T) {
+		_, err := result.BuildChart(chart.Definition{Type: chart.Line, X: "day", Y: []string{"kind"}, Aggregate: chart.AggregateSum})
+		assert.EqualError(t, err, `chart column "kind" contains non-numeric value: "book"`)
+	})
+
+	t.Run("duplicate labels without aggregate", func(t *testing.T) {
+		_, err := result.BuildChart(chart.Definition{Type: chart.Line, X: "day", Y: []string{"total"}, Aggregate: chart.AggregateNone})
+		assert.EqualError(t, err, `chart label "2024-01-01T00:00:00Z" has multiple values, aggregate must be set`)
+	})
+
+	t.Run("aggregate", func(t *testing.T) {
+		data, err := result.BuildChart(chart.Definition{Type: chart.Bar, X: "day", Y: []string{"total", "price"}, Aggregate: chart.AggregateSum})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"2024-01-01T00:00:00Z", "2024-01-02T00:00:00Z"}, data.Labels)
+		assert.Equal(t, []chart.Dataset{
+			{Label: "total", Data: []*float64{val(5), val(5)}},
+			{Label: "price", Data: []*float64{val(10.5), val(20)}},
+		}, data.Datasets)
+	})
+
+	t.Run("series", func(t *testing.T) {
+		data, err := result.BuildChart(chart.Definition{Type: chart.Line, X: "day", Y: []string{"total"}, Series: "kind", Aggregate: chart.AggregateNone})
+		require.NoError(t, err)
+
+		assert.Equal(t, []chart.Dataset{
+			{Label: "book", Data: []*float64{val(2), val(5)}},
+			{Label: "ebook", Data: []*float64{val(3), nil}},
+		}, data.Datasets)
+	})
+
+	t.Run("count", func(t *testing.T) {
+		data, err := result.BuildChart(chart.Definition{Type: chart.Pie, X: "kind", Aggregate: chart.AggregateCount})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"book", "ebook"}, data.Labels)
+		assert.Equal(t, []chart.Dataset{{Label: "count", Data: []*float64{val(2), val(1)}}}, data.Datasets)
+	})
+
+	t.Run("truncated result", func(t *testing.T) {
+		truncated := *result
+		truncated.Stats = &ResultStats{Truncated: true}
+
+		_, err := truncated.BuildChart(chart.Definition{Type: chart.Pie, X: "kind", Aggregate: chart.AggregateCount})
+		assert.Equal(t, errChartTruncated, err)
+	})
+}
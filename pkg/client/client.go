@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log"
@@ -9,6 +10,8 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -18,6 +21,7 @@ import (
 	"github.com/sosedoff/pgweb/pkg/command"
 	"github.com/sosedoff/pgweb/pkg/connection"
 	"github.com/sosedoff/pgweb/pkg/history"
+	"github.com/sosedoff/pgweb/pkg/parser"
 	"github.com/sosedoff/pgweb/pkg/shared"
 	"github.com/sosedoff/pgweb/pkg/statements"
 )
@@ -32,20 +36,40 @@ var (
 	ErrAuthFailed        = errors.New("authentication failed")
 	ErrConnectionRefused = errors.New("connection refused")
 	ErrDatabaseNotExist  = errors.New("database does not exist")
+
+	ErrNotSupported             = errors.New("feature is not supported by the server")
+	ErrReadOnlyMode             = errors.New("operation is not allowed in read-only mode")
+	ErrBackendNotFound          = errors.New("backend process not found")
+	ErrBackendActionReadOnly    = errors.New("backend actions are not allowed in read-only mode")
+	ErrBackendActionUnsupported = errors.New("backend actions are not supported by the server")
+	ErrQueryStatsDisabled       = errors.New("pg_stat_statements extension is not installed")
 )
 
+// queryOptions controls how query rows are fetched
+type queryOptions struct {
+	ctx      context.Context // Parent context, canceling it stops the query
+	limit    int             // Maximum number of rows to fetch, 0 for no limit
+	progress func(rows int)  // Called with the number of rows fetched so far
+	timeout  time.Duration   // Overrides the configured query timeout when set
+}
+
 type Client struct {
 	db               *sqlx.DB
 	tunnel           *Tunnel
 	serverVersion    string
 	serverType       string
-	lastQueryTime    time.Time
+	lastQueryTime    atomic.Pointer[time.Time] // Updated by concurrent queries
 	queryTimeout     time.Duration
+	maxQueryCost     float64
+	maxQueryRows     int64
+	rowLimit         int
 	readonly         bool
 	closed           bool
-	External         bool             `json:"external"`
-	History          []history.Record `json:"history"`
-	ConnectionString string           `json:"connection_string"`
+	history          history.Store
+	historyOnce      sync.Once
+	connectionID     string
+	External         bool   `json:"external"`
+	ConnectionString string `json:"connection_string"`
 }
 
 func getSchemaAndTable(str string) (string, string) {
@@ -75,7 +99,7 @@ func New() (*Client, error) {
 	client := Client{
 		db:               db,
 		ConnectionString: str,
-		History:          history.New(),
+		connectionID:     history.ConnectionID(str),
 	}
 
 	client.init()
@@ -88,6 +112,9 @@ func NewFromUrl(url string, sshInfo *shared.SSHInfo) (*Client, error) {
 		err    error
 	)
 
+	// History is keyed by the remote server address, before any tunnel rewrites
+	connectionID := history.ConnectionID(url)
+
 	if sshInfo != nil {
 		if command.Opts.DisableSSH {
 			return nil, fmt.Errorf("ssh connections are disabled")
@@ -139,7 +166,7 @@ func NewFromUrl(url string, sshInfo *shared.SSHInfo) (*Client, error) {
 		tunnel:           tunnel,
 		serverType:       postgresType,
 		ConnectionString: url,
-		History:          history.New(),
+		connectionID:     connectionID,
 	}
 
 	client.init()
@@ -152,7 +179,11 @@ func NewFromBookmark(bookmark *bookmarks.Bookmark) (*Client, error) {
 		err     error
 	)
 
-	options := bookmark.ConvertToOptions()
+	options, err := bookmark.ConvertToOptions()
+	if err != nil {
+		return nil, err
+	}
+
 	if options.URL != "" {
 		connStr = options.URL
 	} else {
@@ -164,7 +195,10 @@ func NewFromBookmark(bookmark *bookmarks.Bookmark) (*Client, error) {
 
 	var sshInfo *shared.SSHInfo
 	if !bookmark.SSHInfoIsEmpty() {
-		sshInfo = bookmark.SSH
+		sshInfo, err = bookmark.ResolveSSH()
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	client, err := NewFromUrl(connStr, sshInfo)
@@ -176,6 +210,14 @@ func NewFromBookmark(bookmark *bookmarks.Bookmark) (*Client, error) {
 		client.readonly = true
 	}
 
+	// Bookmark query cost limits replace the global ones when set, zero disables the limit
+	if bookmark.MaxQueryCost != nil {
+		client.maxQueryCost = *bookmark.MaxQueryCost
+	}
+	if bookmark.MaxQueryRows != nil {
+		client.maxQueryRows = *bookmark.MaxQueryRows
+	}
+
 	return client, nil
 }
 
@@ -184,6 +226,10 @@ func (client *Client) init() {
 		client.queryTimeout = time.Second * time.Duration(command.Opts.QueryTimeout)
 	}
 
+	client.SetQueryCostLimits(command.Opts.MaxQueryCost, command.Opts.MaxQueryRows)
+	client.SetRowLimit(int(command.Opts.QueryRowLimit))
+	client.historyStore()
+
 	client.setServerVersion()
 }
 
@@ -396,22 +442,76 @@ func (client *Client) Activity() (*Result, error) {
 		return client.query("SHOW QUERIES")
 	}
 
-	version := getMajorMinorVersionString(client.serverVersion)
-	query := statements.Activity[version]
-	if query == "" {
-		query = statements.Activity["default"]
+	return client.query(getVersionedStatement(statements.Activity, client.serverVersion))
+}
+
+// CancelBackend cancels the currently running query of the backend process
+func (client *Client) CancelBackend(pid int) (bool, error) {
+	return client.signalBackend(statements.CancelBackend, pid)
+}
+
+// TerminateBackend terminates the backend process and its database connection
+func (client *Client) TerminateBackend(pid int) (bool, error) {
+	return client.signalBackend(statements.TerminateBackend, pid)
+}
+
+func (client *Client) signalBackend(query string, pid int) (bool, error) {
+	if client.IsReadOnly() {
+		return false, ErrBackendActionReadOnly
+	}
+	if client.serverType == cockroachType {
+		return false, ErrBackendActionUnsupported
+	}
+
+	res, err := client.query(query, pid)
+	if err != nil {
+		return false, err
+	}
+
+	// Only processes connected to the current database are visible in the activity view
+	if len(res.Rows) == 0 {
+		return false, ErrBackendNotFound
 	}
 
-	return client.query(query)
+	result, _ := res.Rows[0][0].(bool)
+	return result, nil
+}
+
+// Query executes the query and returns up to the configured row limit
+func (client *Client) Query(query string, args ...interface{}) (*Result, error) {
+	return client.runQuery(query, queryOptions{limit: client.rowLimit}, args...)
 }
 
-func (client *Client) Query(query string) (*Result, error) {
-	res, err := client.query(query)
+// QueryAll executes the query and returns all rows, ignoring the configured row limit
+func (client *Client) QueryAll(query string, args ...interface{}) (*Result, error) {
+	return client.runQuery(query, queryOptions{}, args...)
+}
+
+// QueryWithProgress executes the query and reports the number of fetched rows via callback.
+// The query is canceled once the context is done.
+func (client *Client) QueryWithProgress(ctx context.Context, query string, full bool, progress func(rows int), args ...interface{}) (*Result, error) {
+	opts := queryOptions{ctx: ctx, progress: progress}
+	if !full {
+		opts.limit = client.rowLimit
+	}
+	return client.runQuery(query, opts, args...)
+}
 
-	// Save history records only if query did not fail
-	if err == nil && !client.hasHistoryRecord(query) {
-		client.History = append(client.History, history.NewRecord(query))
+// QueryWithTimeout executes the query within the given timeout.
+// The configured row limit is applied unless the full result is requested.
+func (client *Client) QueryWithTimeout(query string, timeout time.Duration, full bool, args ...interface{}) (*Result, error) {
+	opts := queryOptions{timeout: timeout}
+	if !full {
+		opts.limit = client.rowLimit
 	}
+	return client.runQuery(query, opts, args...)
+}
+
+func (client *Client) runQuery(query string, opts queryOptions, args ...interface{}) (*Result, error) {
+	start := time.Now()
+	res, err := client.queryWithOptions(opts, query, args...)
+
+	client.addHistoryRecord(query, start, res, err)
 
 	return res, err
 }
@@ -430,6 +530,16 @@ func (client *Client) SetReadOnlyMode() error {
 	return nil
 }
 
+// SetRowLimit sets the maximum number of rows returned by interactive queries
+func (client *Client) SetRowLimit(limit int) {
+	client.rowLimit = limit
+}
+
+// IsReadOnly returns true if connection is running in read-only mode
+func (client *Client) IsReadOnly() bool {
+	return command.Opts.ReadOnly || client.readonly
+}
+
 func (client *Client) ServerVersionInfo() string {
 	return fmt.Sprintf("%s %s", client.serverType, client.serverVersion)
 }
@@ -439,16 +549,25 @@ func (client *Client) ServerVersion() string {
 }
 
 func (client *Client) context() (context.Context, context.CancelFunc) {
-	if client.queryTimeout > 0 {
-		return context.WithTimeout(context.Background(), client.queryTimeout)
-	}
-	return context.Background(), func() {}
+	return client.contextWithTimeout(context.Background(), 0)
 }
 
-func (client *Client) exec(query string, args ...interface{}) (*Result, error) {
-	ctx, cancel := client.context()
-	defer cancel()
+// contextWithTimeout returns the query context, the configured query timeout is used by default.
+// Given timeout could not exceed the configured query timeout.
+func (client *Client) contextWithTimeout(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	if timeout == 0 || (client.queryTimeout > 0 && client.queryTimeout < timeout) {
+		timeout = client.queryTimeout
+	}
+	if timeout > 0 {
+		return context.WithTimeout(parent, timeout)
+	}
+	return context.WithCancel(parent)
+}
 
+func (client *Client) exec(ctx context.Context, query string, args ...interface{}) (*Result, error) {
 	queryStart := time.Now()
 	res, err := client.db.ExecContext(ctx, query, args...)
 	queryFinish := time.Now()
@@ -479,38 +598,88 @@ func (client *Client) exec(query string, args ...interface{}) (*Result, error) {
 }
 
 func (client *Client) query(query string, args ...interface{}) (*Result, error) {
+	return client.queryWithOptions(queryOptions{}, query, args...)
+}
+
+// queryWithOptions executes the query and stops reading rows once the limit is reached.
+// Limit is disabled when set to zero.
+func (client *Client) queryWithOptions(opts queryOptions, query string, args ...interface{}) (*Result, error) {
 	if client.db == nil {
 		return nil, nil
 	}
 
 	// Update the last usage time
-	defer func() {
-		client.lastQueryTime = time.Now().UTC()
-	}()
+	defer client.touch()
 
-	// We're going to force-set transaction mode on every query.
-	// This is needed so that default mode could not be changed by user.
-	if command.Opts.ReadOnly || client.readonly {
-		if err := client.SetReadOnlyMode(); err != nil {
-			return nil, err
-		}
-		if containsRestrictedKeywords(query) {
-			return nil, errors.New("query contains keywords not allowed in read-only mode")
-		}
+	if err := client.enforceReadOnly(query); err != nil {
+		return nil, err
 	}
 
 	action := strings.ToLower(strings.Split(query, " ")[0])
 	hasReturnValues := strings.Contains(strings.ToLower(query), " returning ")
 
+	ctx, cancel := client.contextWithTimeout(opts.ctx, opts.timeout)
+	defer cancel()
+
 	if (action == "update" || action == "delete") && !hasReturnValues {
-		return client.exec(query, args...)
+		return client.exec(ctx, query, args...)
+	}
+
+	return client.fetchResult(ctx, client.db, opts, query, args...)
+}
+
+// queryWithRollback executes the query inside of a transaction that is always
+// rolled back afterwards, so any changes made by the query are discarded.
+func (client *Client) queryWithRollback(query string, args ...interface{}) (*Result, error) {
+	if client.db == nil {
+		return nil, nil
+	}
+
+	// Update the last usage time
+	defer client.touch()
+
+	if err := client.enforceReadOnly(query); err != nil {
+		return nil, err
 	}
 
 	ctx, cancel := client.context()
 	defer cancel()
 
+	tx, err := client.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: client.IsReadOnly()})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() //nolint
+
+	return client.fetchResult(ctx, tx, queryOptions{}, query, args...)
+}
+
+// enforceReadOnly makes sure that the query is allowed to run in read-only mode
+func (client *Client) enforceReadOnly(query string) error {
+	if !client.IsReadOnly() {
+		return nil
+	}
+
+	// We're going to force-set transaction mode on every query.
+	// This is needed so that default mode could not be changed by user.
+	if err := client.SetReadOnlyMode(); err != nil {
+		return err
+	}
+	if err := parser.CheckReadOnly(query); err != nil {
+		return fmt.Errorf("query is not allowed in read-only mode: %w", err)
+	}
+
+	return nil
+}
+
+// fetchResult runs the query using a database connection or transaction and collects
+// rows until the limit is reached
+func (client *Client) fetchResult(ctx context.Context, db sqlx.QueryerContext, opts queryOptions, query string, args ...interface{}) (*Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	queryStart := time.Now()
-	rows, err := client.db.QueryxContext(ctx, query, args...)
+	rows, err := db.QueryxContext(ctx, query, args...)
 	queryFinish := time.Now()
 	if err != nil {
 		if command.Opts.Debug {
@@ -534,8 +703,17 @@ func (client *Client) query(query string, args ...interface{}) (*Result, error)
 		Columns: cols,
 		Rows:    []Row{},
 	}
+	truncated := false
 
 	for rows.Next() {
+		if opts.limit > 0 && len(result.Rows) >= opts.limit {
+			// Closing the rows would read the rest of the result, the query is canceled
+			// instead so the server stops sending rows
+			truncated = true
+			cancel()
+			break
+		}
+
 		obj, err := rows.SliceScan()
 
 		for i, item := range obj {
@@ -552,12 +730,22 @@ func (client *Client) query(query string, args ...interface{}) (*Result, error)
 
 		if err == nil {
 			result.Rows = append(result.Rows, obj)
+			if opts.progress != nil {
+				opts.progress(len(result.Rows))
+			}
 		}
 	}
 
+	// Partial results of canceled or timed out queries are errors, unless the query
+	// was canceled by the row limit above
+	if err := rows.Err(); err != nil && !(truncated && errors.Is(err, context.Canceled)) {
+		return nil, err
+	}
+
 	result.Stats = &ResultStats{
 		ColumnsCount:    len(cols),
 		RowsCount:       len(result.Rows),
+		Truncated:       truncated,
 		QueryStartTime:  queryStart.UTC(),
 		QueryFinishTime: queryFinish.UTC(),
 		QueryDuration:   queryFinish.Sub(queryStart).Milliseconds(),
@@ -594,11 +782,20 @@ func (c *Client) IsClosed() bool {
 }
 
 func (c *Client) LastQueryTime() time.Time {
-	return c.lastQueryTime
+	if ts := c.lastQueryTime.Load(); ts != nil {
+		return *ts
+	}
+	return time.Time{}
+}
+
+// touch updates the last usage time of the client
+func (c *Client) touch() {
+	ts := time.Now().UTC()
+	c.lastQueryTime.Store(&ts)
 }
 
 func (client *Client) IsIdle() bool {
-	mins := int(time.Since(client.lastQueryTime).Minutes())
+	mins := int(time.Since(client.LastQueryTime()).Minutes())
 
 	if command.Opts.ConnectionIdleTimeout > 0 {
 		return mins >= command.Opts.ConnectionIdleTimeout
@@ -625,19 +822,6 @@ func (client *Client) fetchRows(q string) ([]string, error) {
 	return results, nil
 }
 
-func (client *Client) hasHistoryRecord(query string) bool {
-	result := false
-
-	for _, record := range client.History {
-		if record.Query == query {
-			result = true
-			break
-		}
-	}
-
-	return result
-}
-
 type ConnContext struct {
 	Host     string
 	User     string
@@ -667,7 +851,7 @@ func (client *Client) GetConnContext() (*ConnContext, error) {
 		Mode: "default",
 	}
 
-	if command.Opts.ReadOnly {
+	if client.IsReadOnly() {
 		connCtx.Mode = "readonly"
 	}
 
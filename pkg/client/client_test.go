@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -8,6 +9,7 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -15,6 +17,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/sosedoff/pgweb/pkg/command"
+	"github.com/sosedoff/pgweb/pkg/history"
 )
 
 var (
@@ -196,7 +199,7 @@ func testClientIdleTime(t *testing.T) {
 	}
 
 	for ts, expected := range examples {
-		testClient.lastQueryTime = ts
+		testClient.lastQueryTime.Store(&ts)
 		assert.Equal(t, expected, testClient.IsIdle())
 	}
 }
@@ -306,6 +309,68 @@ func testActivity(t *testing.T) {
 	assertMatches(t, expected, res.Columns)
 }
 
+func testBackendActions(t *testing.T) {
+	t.Run("unknown process", func(t *testing.T) {
+		_, err := testClient.CancelBackend(0)
+		assert.Equal(t, ErrBackendNotFound, err)
+
+		_, err = testClient.TerminateBackend(0)
+		assert.Equal(t, ErrBackendNotFound, err)
+	})
+
+	t.Run("read-only mode", func(t *testing.T) {
+		command.Opts.ReadOnly = true
+		defer func() {
+			command.Opts.ReadOnly = false
+		}()
+
+		_, err := testClient.CancelBackend(0)
+		assert.Equal(t, ErrBackendActionReadOnly, err)
+	})
+}
+
+func testProgress(t *testing.T) {
+	res, err := testClient.Progress()
+	require.NoError(t, err)
+	require.NotNil(t, res["vacuum"])
+
+	assert.Contains(t, res["vacuum"].Columns, "pid")
+	assert.Contains(t, res["vacuum"].Columns, "relation")
+	assert.Contains(t, res["vacuum"].Columns, "progress_pct")
+	assert.Contains(t, res["vacuum"].Columns, "duration_sec")
+}
+
+func testReplication(t *testing.T) {
+	role, err := testClient.ServerRole()
+	assert.NoError(t, err)
+	assert.Equal(t, ServerRolePrimary, role)
+
+	res, err := testClient.RecoveryStatus()
+	require.NoError(t, err)
+	assert.Equal(t, false, res.Rows[0][0])
+	assert.Equal(t, "primary", res.Rows[0][1])
+
+	res, err = testClient.Replicas()
+	assert.NoError(t, err)
+	assert.Contains(t, res.Columns, "replay_lag_bytes")
+
+	res, err = testClient.ReplicationSlots()
+	assert.NoError(t, err)
+	assert.Contains(t, res.Columns, "retained_wal_bytes")
+
+	_, err = testClient.WalReceiver()
+	assert.NoError(t, err)
+
+	major, _ := pgVersion()
+	if major >= 10 {
+		_, err = testClient.Publications()
+		assert.NoError(t, err)
+
+		_, err = testClient.Subscriptions()
+		assert.NoError(t, err)
+	}
+}
+
 func testDatabases(t *testing.T) {
 	res, err := testClient.Databases()
 	assert.NoError(t, err)
@@ -517,6 +582,21 @@ func testQuery(t *testing.T) {
 		assert.Equal(t, "pq: canceling statement due to user request", err.Error())
 		assert.Nil(t, res)
 	})
+
+	t.Run("concurrent queries", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := testClient.QueryWithTimeout("SELECT * FROM books", time.Second, false)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		assert.WithinDuration(t, time.Now(), testClient.LastQueryTime(), time.Minute)
+	})
 }
 
 func testUpdateQuery(t *testing.T) {
@@ -623,19 +703,28 @@ func testResult(t *testing.T) {
 func testHistory(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		_, err := testClient.Query("SELECT * FROM books WHERE id = 12345")
-		query := testClient.History[len(testClient.History)-1].Query
 		assert.NoError(t, err)
-		assert.Equal(t, "SELECT * FROM books WHERE id = 12345", query)
+
+		records, _, err := testClient.History(history.Filter{Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM books WHERE id = 12345", records[0].Query)
+		assert.Equal(t, fmt.Sprintf("%s@%s:%s/%s", serverUser, serverHost, serverPort, serverDatabase), records[0].Connection)
+		assert.True(t, records[0].Success)
+		assert.Equal(t, 0, records[0].RowsCount)
 	})
 
 	t.Run("failed query", func(t *testing.T) {
 		_, err := testClient.Query("SELECT * FROM books123")
-		query := testClient.History[len(testClient.History)-1].Query
 		assert.NotNil(t, err)
-		assert.NotEqual(t, "SELECT * FROM books123", query)
+
+		records, _, err := testClient.History(history.Filter{Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM books123", records[0].Query)
+		assert.False(t, records[0].Success)
+		assert.Contains(t, records[0].Error, `relation "books123" does not exist`)
 	})
 
-	t.Run("unique queries", func(t *testing.T) {
+	t.Run("all executions", func(t *testing.T) {
 		url := fmt.Sprintf("postgres://%s@%s:%s/%s?sslmode=disable", serverUser, serverHost, serverPort, serverDatabase)
 
 		client, _ := NewFromUrl(url, nil)
@@ -646,8 +735,18 @@ func testHistory(t *testing.T) {
 			assert.NoError(t, err)
 		}
 
-		assert.Equal(t, 1, len(client.History))
-		assert.Equal(t, "SELECT * FROM books WHERE id = 1", client.History[0].Query)
+		records, total, err := client.History(history.Filter{})
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		assert.Equal(t, "SELECT * FROM books WHERE id = 1", records[0].Query)
+
+		require.NoError(t, client.DeleteHistoryRecord(records[0].ID))
+		_, total, _ = client.History(history.Filter{})
+		assert.Equal(t, 2, total)
+
+		require.NoError(t, client.ClearHistory())
+		_, total, _ = client.History(history.Filter{})
+		assert.Equal(t, 0, total)
 	})
 }
 
@@ -666,7 +765,7 @@ func testReadOnlyMode(t *testing.T) {
 
 	_, err = client.Query("\nCREATE TABLE foobar(id integer);\n")
 	assert.NotNil(t, err)
-	assert.Error(t, err, "query contains keywords not allowed in read-only mode")
+	assert.Error(t, err, "query is not allowed in read-only mode: CREATE statement is not allowed")
 
 	// Turn off guard
 	_, err = client.db.Exec("SET default_transaction_read_only=off;")
@@ -674,7 +773,13 @@ func testReadOnlyMode(t *testing.T) {
 
 	_, err = client.Query("\nCREATE TABLE foobar(id integer);\n")
 	assert.NotNil(t, err)
-	assert.Contains(t, err.Error(), "query contains keywords not allowed in read-only mode")
+	assert.Contains(t, err.Error(), "query is not allowed in read-only mode")
+
+	_, err = client.Query("SELECT pg_terminate_backend(pg_backend_pid())")
+	assert.EqualError(t, err, "query is not allowed in read-only mode: function pg_terminate_backend is not allowed")
+
+	_, err = client.Query("SELECT 'CREATE TABLE foobar(id integer);'")
+	assert.NoError(t, err)
 
 	_, err = client.Query("-- CREATE TABLE foobar(id integer);\nSELECT 'foo';")
 	assert.NoError(t, err)
@@ -687,7 +792,7 @@ func testReadOnlyMode(t *testing.T) {
 		client.readonly = true
 
 		_, err := client.Query("INSERT INTO foobar(id) VALUES(1)")
-		assert.Error(t, err, "query contains keywords not allowed in read-only mode")
+		assert.Error(t, err, "query is not allowed in read-only mode: INSERT statement is not allowed")
 	})
 }
 
@@ -710,6 +815,284 @@ func testTablesStats(t *testing.T) {
 	assert.Equal(t, columns, result.Columns)
 }
 
+func testIndexDiagnostics(t *testing.T) {
+	report, err := testClient.IndexDiagnostics()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"schema_name", "table_name", "index_name", "index_scans", "index_size", "index_size_bytes", "suggestion"}, report.Unused.Columns)
+	assert.Equal(t, []string{"schema_name", "table_name", "index_name", "covered_by", "kind", "index_size", "index_size_bytes", "suggestion"}, report.Duplicate.Columns)
+	assert.Equal(t, []string{"schema_name", "table_name", "index_name", "index_size", "index_size_bytes", "index_definition", "suggestion"}, report.Invalid.Columns)
+	assert.Equal(t, []string{"schema_name", "table_name", "constraint_name", "referenced_table", "columns", "table_size", "table_size_bytes", "suggestion"}, report.MissingFK.Columns)
+	assert.Equal(t, []string{"schema_name", "table_name", "seq_scan", "seq_tup_read", "idx_scan", "estimated_rows", "table_size", "table_size_bytes", "suggestion"}, report.SeqScans.Columns)
+
+	t.Run("overlapping index", func(t *testing.T) {
+		testClient.db.MustExec("CREATE INDEX books_author_idx ON books (author_id)")
+		testClient.db.MustExec("CREATE INDEX books_author_subject_idx ON books (author_id, subject_id)")
+		defer testClient.db.MustExec("DROP INDEX books_author_idx, books_author_subject_idx")
+
+		report, err := testClient.IndexDiagnostics()
+		require.NoError(t, err)
+		require.Len(t, report.Duplicate.Rows, 1)
+		assert.Equal(t, "books_author_idx", report.Duplicate.Rows[0][2])
+		assert.Equal(t, "books_author_subject_idx", report.Duplicate.Rows[0][3])
+		assert.Equal(t, "overlapping", report.Duplicate.Rows[0][4])
+		assert.Equal(t, `DROP INDEX CONCURRENTLY "public"."books_author_idx";`, report.Duplicate.Rows[0][7])
+	})
+}
+
+func testBloatReport(t *testing.T) {
+	columns := []string{
+		"schema_name",
+		"table_name",
+		"table_size",
+		"table_size_bytes",
+		"wasted_size",
+		"wasted_bytes",
+		"bloat_ratio",
+		"fillfactor",
+		"is_estimated",
+		"is_analyzed",
+	}
+
+	report, err := testClient.BloatReport()
+	require.NoError(t, err)
+	assert.Equal(t, false, report.Pgstattuple)
+	assert.Empty(t, report.PgstattupleError)
+	assert.Equal(t, columns, report.Tables.Columns)
+	assert.NotEmpty(t, report.Tables.Rows)
+	assert.Equal(t, "index_name", report.Indexes.Columns[2])
+	assert.Equal(t, "wasted_bytes", report.Indexes.Columns[6])
+}
+
+func testQueryStats(t *testing.T) {
+	available, err := testClient.QueryStatsAvailable()
+	require.NoError(t, err)
+
+	// Extension requires shared_preload_libraries setting which is not set on test servers
+	if !available {
+		_, err := testClient.QueryStats(QueryStatsOptions{})
+		assert.Equal(t, ErrQueryStatsDisabled, err)
+		assert.Equal(t, ErrQueryStatsDisabled, testClient.ResetQueryStats())
+		return
+	}
+
+	res, err := testClient.QueryStats(QueryStatsOptions{SortColumn: "calls", Limit: 5})
+	require.NoError(t, err)
+	assert.Equal(t, "queryid", res.Columns[0])
+	assert.LessOrEqual(t, len(res.Rows), 5)
+
+	_, err = testClient.QueryStats(QueryStatsOptions{SortColumn: "query"})
+	assert.EqualError(t, err, `invalid sort column: "query"`)
+
+	_, err = testClient.QueryStats(QueryStatsOptions{SortOrder: "random"})
+	assert.EqualError(t, err, `invalid sort order: "random"`)
+
+	t.Run("reset in read-only mode", func(t *testing.T) {
+		command.Opts.ReadOnly = true
+		defer func() {
+			command.Opts.ReadOnly = false
+		}()
+
+		assert.Equal(t, ErrReadOnlyMode, testClient.ResetQueryStats())
+	})
+}
+
+func testExplainQuery(t *testing.T) {
+	plan, err := testClient.ExplainQuery("SELECT * FROM books ORDER BY title")
+	require.NoError(t, err)
+	assert.False(t, plan.Analyzed)
+	assert.NotEmpty(t, plan.Plan.NodeType)
+	assert.Greater(t, plan.Plan.TotalCost, 0.0)
+
+	plan, err = testClient.AnalyzeQuery("SELECT * FROM books ORDER BY title")
+	require.NoError(t, err)
+	assert.True(t, plan.Analyzed)
+	assert.Equal(t, float64(15), plan.Plan.ActualRows)
+	assert.Equal(t, float64(1), plan.Plan.ActualLoops)
+	assert.Greater(t, plan.ExecutionTime, 0.0)
+
+	_, err = testClient.ExplainQuery("SELECT * FROM missing_table")
+	assert.EqualError(t, err, `pq: relation "missing_table" does not exist`)
+}
+
+func testAnalyzeModifyingQuery(t *testing.T) {
+	plan, err := testClient.AnalyzeQuery("UPDATE books SET title = 'Analyzed' WHERE id = 7808")
+	require.NoError(t, err)
+	assert.True(t, plan.Analyzed)
+	assert.True(t, plan.RolledBack)
+	assert.Equal(t, "ModifyTable", plan.Plan.NodeType)
+
+	res, err := testClient.query("SELECT title FROM books WHERE id = 7808")
+	require.NoError(t, err)
+	assert.Equal(t, "The Shining", res.Rows[0][0])
+
+	// Functions called by read-only statements could modify data as well
+	plan, err = testClient.AnalyzeQuery("SELECT set_config('pgweb.analyzed', 'true', false)")
+	require.NoError(t, err)
+	assert.True(t, plan.RolledBack)
+
+	res, err = testClient.query("SELECT current_setting('pgweb.analyzed', true)")
+	require.NoError(t, err)
+	assert.Equal(t, "", res.Rows[0][0])
+
+	_, err = testClient.AnalyzeQuery("VACUUM books")
+	assert.Equal(t, ErrAnalyzeNotAllowed, err)
+
+	// Text plan fallback is rolled back as well
+	_, err = testClient.AnalyzeQueryText("UPDATE books SET title = 'Analyzed' WHERE id = 7808")
+	require.NoError(t, err)
+
+	res, err = testClient.query("SELECT title FROM books WHERE id = 7808")
+	require.NoError(t, err)
+	assert.Equal(t, "The Shining", res.Rows[0][0])
+
+	_, err = testClient.AnalyzeQueryText("VACUUM books")
+	assert.Equal(t, ErrAnalyzeNotAllowed, err)
+}
+
+func testQueryCost(t *testing.T) {
+	defer testClient.SetQueryCostLimits(0, 0)
+
+	estimate, err := testClient.CheckQueryCost("SELECT * FROM books")
+	assert.NoError(t, err)
+	assert.Nil(t, estimate)
+
+	testClient.SetQueryCostLimits(0, 10)
+
+	estimate, err = testClient.CheckQueryCost("SELECT * FROM books WHERE id = 7808")
+	assert.NoError(t, err)
+	assert.False(t, estimate.Exceeded)
+	assert.Equal(t, int64(10), estimate.MaxRows)
+
+	estimate, err = testClient.CheckQueryCost("SELECT * FROM books; SELECT * FROM authors")
+	assert.ErrorIs(t, err, ErrQueryCostExceeded)
+	assert.True(t, estimate.Exceeded)
+	assert.Greater(t, estimate.Rows, float64(10))
+	assert.Greater(t, estimate.Cost, float64(0))
+
+	// Statements that do not need a plan are skipped
+	estimate, err = testClient.CheckQueryCost("SHOW search_path")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(0), estimate.Rows)
+
+	// Queries that could not be estimated are not allowed
+	_, err = testClient.CheckQueryCost("SHOW search_path; SELECT * FROM missing_table")
+	assert.ErrorIs(t, err, ErrQueryCostUnknown)
+
+	estimate, err = testClient.CheckQueryCost("SELECT * FROM books WHERE id = $1", int64(7808))
+	assert.NoError(t, err)
+	assert.False(t, estimate.Exceeded)
+	assert.Greater(t, estimate.Cost, float64(0))
+
+	testClient.SetQueryCostLimits(0.01, 0)
+
+	_, err = testClient.CheckQueryCost("SELECT * FROM books WHERE id = 7808")
+	assert.ErrorIs(t, err, ErrQueryCostExceeded)
+}
+
+func testQueryRowLimit(t *testing.T) {
+	testClient.SetRowLimit(5)
+	defer testClient.SetRowLimit(0)
+
+	res, err := testClient.Query("SELECT * FROM books")
+	require.NoError(t, err)
+	assert.Len(t, res.Rows, 5)
+	assert.Equal(t, 5, res.Stats.RowsCount)
+	assert.True(t, res.Stats.Truncated)
+
+	res, err = testClient.Query("SELECT * FROM books LIMIT 5")
+	require.NoError(t, err)
+	assert.Len(t, res.Rows, 5)
+	assert.False(t, res.Stats.Truncated)
+
+	res, err = testClient.QueryAll("SELECT * FROM books")
+	require.NoError(t, err)
+	assert.Len(t, res.Rows, 15)
+	assert.False(t, res.Stats.Truncated)
+
+	// Query is canceled once the limit is reached, remaining rows are not read
+	start := time.Now()
+	res, err = testClient.Query("SELECT i, pg_sleep(CASE WHEN i > 6 THEN 1 ELSE 0 END) FROM generate_series(1, 10) i")
+	require.NoError(t, err)
+	assert.Len(t, res.Rows, 5)
+	assert.True(t, res.Stats.Truncated)
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	res, err = testClient.Query("SELECT 1")
+	require.NoError(t, err)
+	assert.Len(t, res.Rows, 1)
+}
+
+func testQueryWithArgs(t *testing.T) {
+	res, err := testClient.Query("SELECT id, title FROM books WHERE id = $1", int64(7808))
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(res.Rows))
+	assert.Equal(t, "The Shining", res.Rows[0][1])
+}
+
+func testQueryWithProgress(t *testing.T) {
+	testClient.SetRowLimit(5)
+	defer testClient.SetRowLimit(0)
+
+	progress := []int{}
+	res, err := testClient.QueryWithProgress(context.Background(), "SELECT * FROM books", false, func(rows int) {
+		progress = append(progress, rows)
+	})
+	require.NoError(t, err)
+	assert.Len(t, res.Rows, 5)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, progress)
+
+	res, err = testClient.QueryWithProgress(context.Background(), "SELECT * FROM books", true, func(rows int) {})
+	require.NoError(t, err)
+	assert.Len(t, res.Rows, 15)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	_, err = testClient.QueryWithProgress(ctx, "SELECT pg_sleep(5)", true, func(rows int) {})
+	assert.Error(t, err)
+
+	// Query canceled while rows are streaming does not return partial rows
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	res, err = testClient.QueryWithProgress(ctx, "SELECT i, pg_sleep(CASE WHEN i > 2 THEN 1 ELSE 0 END) FROM generate_series(1, 5) i", true, func(rows int) {
+		if rows == 2 {
+			cancel()
+		}
+	})
+	assert.Error(t, err)
+	assert.Nil(t, res)
+}
+
+func testQueryWithTimeout(t *testing.T) {
+	_, err := testClient.QueryWithTimeout("SELECT pg_sleep(1)", 100*time.Millisecond, false)
+	assert.Error(t, err)
+
+	res, err := testClient.QueryWithTimeout("SELECT * FROM books", time.Second, false)
+	require.NoError(t, err)
+	assert.Len(t, res.Rows, 15)
+
+	// Row limit is not applied to full results
+	testClient.SetRowLimit(10)
+	defer testClient.SetRowLimit(0)
+
+	res, err = testClient.QueryWithTimeout("SELECT * FROM books", time.Second, false)
+	require.NoError(t, err)
+	assert.Len(t, res.Rows, 10)
+
+	res, err = testClient.QueryWithTimeout("SELECT * FROM books", time.Second, true)
+	require.NoError(t, err)
+	assert.Len(t, res.Rows, 15)
+
+	// Configured query timeout could not be exceeded
+	testClient.queryTimeout = 100 * time.Millisecond
+	defer func() { testClient.queryTimeout = 0 }()
+
+	_, err = testClient.QueryWithTimeout("SELECT pg_sleep(1)", time.Minute, false)
+	assert.Error(t, err)
+}
+
 func testConnContext(t *testing.T) {
 	result, err := testClient.GetConnContext()
 	assert.NoError(t, err)
@@ -762,6 +1145,9 @@ func TestAll(t *testing.T) {
 	testTest(t)
 	testInfo(t)
 	testActivity(t)
+	testBackendActions(t)
+	testProgress(t)
+	testReplication(t)
 	testDatabases(t)
 	testSchemas(t)
 	testObjects(t)
@@ -783,6 +1169,16 @@ func TestAll(t *testing.T) {
 	testReadOnlyMode(t)
 	testDumpExport(t)
 	testTablesStats(t)
+	testIndexDiagnostics(t)
+	testBloatReport(t)
+	testQueryStats(t)
+	testExplainQuery(t)
+	testAnalyzeModifyingQuery(t)
+	testQueryCost(t)
+	testQueryRowLimit(t)
+	testQueryWithArgs(t)
+	testQueryWithProgress(t)
+	testQueryWithTimeout(t)
 	testConnContext(t)
 	testServerSettings(t)
 
@@ -0,0 +1,192 @@
+package client
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sosedoff/pgweb/pkg/statements"
+)
+
+var (
+	// Index definition prefix, used to turn it into a concurrent build statement
+	reIndexDefinition = regexp.MustCompile(`^CREATE (UNIQUE )?INDEX`)
+)
+
+// IndexDiagnostics returns reports about unused, duplicate, invalid and missing indexes
+func (client *Client) IndexDiagnostics() (*IndexDiagnostics, error) {
+	if client.serverType == cockroachType {
+		return nil, ErrNotSupported
+	}
+
+	report := IndexDiagnostics{}
+
+	sections := []struct {
+		result     **Result
+		query      string
+		suggestion func(map[string]interface{}) string
+	}{
+		{&report.Unused, statements.IndexUnused, suggestDropIndex},
+		{&report.Duplicate, statements.IndexDuplicate, suggestDropIndex},
+		{&report.Invalid, statements.IndexInvalid, suggestRebuildIndex},
+		{&report.MissingFK, statements.IndexMissingFK, suggestForeignKeyIndex},
+		{&report.SeqScans, statements.IndexSeqScans, suggestSeqScanReview},
+	}
+
+	for _, section := range sections {
+		res, err := client.query(section.query)
+		if err != nil {
+			return nil, err
+		}
+
+		addSuggestions(res, section.suggestion)
+		*section.result = res
+	}
+
+	return &report, nil
+}
+
+// BloatReport returns wasted space estimates for tables and indexes. Tables and
+// btree indexes stats are fetched with pgstattuple extension when it's installed
+// and accessible, otherwise the estimates are based on the catalog statistics.
+func (client *Client) BloatReport() (*BloatReport, error) {
+	if client.serverType == cockroachType {
+		return nil, ErrNotSupported
+	}
+
+	report := BloatReport{}
+
+	installed, err := client.hasExtension("pgstattuple")
+	if err != nil {
+		return nil, err
+	}
+
+	if installed {
+		// Fall back to estimates if user does not have access to pgstattuple functions,
+		// the error is included into the report so it's not mistaken for missing extension.
+		if err := client.pgstattupleBloat(&report); err != nil {
+			report.PgstattupleError = err.Error()
+		}
+	}
+
+	if !report.Pgstattuple {
+		report.Tables, err = client.query(statements.TableBloat)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Indexes, err = client.query(statements.IndexBloat)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &report, nil
+}
+
+func (client *Client) pgstattupleBloat(report *BloatReport) error {
+	tables, err := client.query(statements.TableBloatPgstattuple)
+	if err != nil {
+		return err
+	}
+
+	indexes, err := client.query(statements.IndexBloatPgstattuple)
+	if err != nil {
+		return err
+	}
+
+	report.Tables = tables
+	report.Indexes = indexes
+	report.Pgstattuple = true
+
+	return nil
+}
+
+// Progress returns the progress of running maintenance operations, such as vacuum,
+// analyze or index builds, grouped by the operation name.
+func (client *Client) Progress() (map[string]*Result, error) {
+	if client.serverType == cockroachType {
+		return nil, ErrNotSupported
+	}
+
+	queries := map[string]string{
+		"vacuum":       statements.ProgressVacuum,
+		"analyze":      statements.ProgressAnalyze,
+		"create_index": statements.ProgressCreateIndex,
+		"cluster":      statements.ProgressCluster,
+		"copy":         statements.ProgressCopy,
+	}
+
+	report := map[string]*Result{}
+
+	for _, name := range getVersionedStatement(statements.Progress, client.serverVersion) {
+		res, err := client.query(queries[name])
+		if err != nil {
+			return nil, err
+		}
+		report[name] = res
+	}
+
+	return report, nil
+}
+
+// hasExtension returns true if extension is installed in the current database
+func (client *Client) hasExtension(name string) (bool, error) {
+	res, err := client.query(statements.ExtensionInstalled, name)
+	if err != nil {
+		return false, err
+	}
+
+	installed, _ := res.Rows[0][0].(bool)
+	return installed, nil
+}
+
+// addSuggestions appends a column with the suggested SQL for every row of the result
+func addSuggestions(res *Result, fn func(map[string]interface{}) string) {
+	for i, item := range res.Format() {
+		res.Rows[i] = append(res.Rows[i], fn(item))
+	}
+
+	res.Columns = append(res.Columns, "suggestion")
+	if res.Stats != nil {
+		res.Stats.ColumnsCount = len(res.Columns)
+	}
+}
+
+// quoteIdent returns a quoted SQL identifier
+func quoteIdent(name interface{}) string {
+	return `"` + strings.ReplaceAll(fmt.Sprint(name), `"`, `""`) + `"`
+}
+
+func suggestDropIndex(item map[string]interface{}) string {
+	return fmt.Sprintf(
+		"DROP INDEX CONCURRENTLY %s.%s;",
+		quoteIdent(item["schema_name"]),
+		quoteIdent(item["index_name"]),
+	)
+}
+
+func suggestRebuildIndex(item map[string]interface{}) string {
+	definition := fmt.Sprint(item["index_definition"])
+	definition = reIndexDefinition.ReplaceAllString(definition, "CREATE ${1}INDEX CONCURRENTLY")
+
+	return fmt.Sprintf("%s %s;", suggestDropIndex(item), definition)
+}
+
+func suggestForeignKeyIndex(item map[string]interface{}) string {
+	return fmt.Sprintf(
+		"CREATE INDEX CONCURRENTLY ON %s.%s (%s);",
+		quoteIdent(item["schema_name"]),
+		quoteIdent(item["table_name"]),
+		item["columns"],
+	)
+}
+
+func suggestSeqScanReview(item map[string]interface{}) string {
+	return fmt.Sprintf(
+		"-- %v rows read by sequential scans, review filters used on %s.%s",
+		item["seq_tup_read"],
+		quoteIdent(item["schema_name"]),
+		quoteIdent(item["table_name"]),
+	)
+}
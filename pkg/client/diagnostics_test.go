@@ -0,0 +1,69 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sosedoff/pgweb/pkg/parser"
+	"github.com/sosedoff/pgweb/pkg/statements"
+)
+
+func TestDiagnosticsStatements(t *testing.T) {
+	// Diagnostics must be available in read-only mode
+	examples := map[string]string{
+		"index_unused":     statements.IndexUnused,
+		"index_duplicate":  statements.IndexDuplicate,
+		"index_invalid":    statements.IndexInvalid,
+		"index_missing_fk": statements.IndexMissingFK,
+		"index_seq_scans":  statements.IndexSeqScans,
+		"table_bloat":      statements.TableBloat,
+		"table_bloat_pgst": statements.TableBloatPgstattuple,
+		"index_bloat":      statements.IndexBloat,
+		"index_bloat_pgst": statements.IndexBloatPgstattuple,
+		"progress_vacuum":  statements.ProgressVacuum,
+		"progress_analyze": statements.ProgressAnalyze,
+		"progress_index":   statements.ProgressCreateIndex,
+		"progress_cluster": statements.ProgressCluster,
+		"progress_copy":    statements.ProgressCopy,
+		"recovery_status":  statements.RecoveryStatus["default"],
+		"replicas":         statements.Replicas["default"],
+		"slots":            statements.ReplicationSlots["default"],
+		"wal_receiver":     statements.WalReceiver["default"],
+		"publications":     statements.Publications["default"],
+		"subscriptions":    statements.Subscriptions["default"],
+		"query_stats":      statements.QueryStats,
+		"query_stats_1.7":  statements.QueryStatsLegacy,
+		"query_stats_1.2":  statements.QueryStatsBasic,
+	}
+
+	for name, query := range examples {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, parser.CheckReadOnly(query))
+		})
+	}
+}
+
+func TestAddSuggestions(t *testing.T) {
+	result := &Result{
+		Columns: []string{"schema_name", "table_name", "index_name", "index_definition"},
+		Rows: []Row{
+			{"public", "books", "books_idx", "CREATE INDEX books_idx ON public.books USING btree (title)"},
+			{"my schema", "users", `users"idx`, "CREATE UNIQUE INDEX ..."},
+		},
+		Stats: &ResultStats{ColumnsCount: 4},
+	}
+
+	addSuggestions(result, suggestRebuildIndex)
+
+	assert.Equal(t, "suggestion", result.Columns[4])
+	assert.Equal(t, 5, result.Stats.ColumnsCount)
+	assert.Equal(t,
+		`DROP INDEX CONCURRENTLY "public"."books_idx"; CREATE INDEX CONCURRENTLY books_idx ON public.books USING btree (title);`,
+		result.Rows[0][4],
+	)
+	assert.Equal(t,
+		`DROP INDEX CONCURRENTLY "my schema"."users""idx"; CREATE UNIQUE INDEX CONCURRENTLY ...;`,
+		result.Rows[1][4],
+	)
+}
@@ -0,0 +1,368 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sosedoff/pgweb/pkg/parser"
+)
+
+const (
+	// Number of scanned rows that makes sequential scan worth reviewing
+	seqScanRowsThreshold = 100000
+
+	// Number of outer rows that makes nested loop worth reviewing
+	nestedLoopRowsThreshold = 10000
+
+	// Ratio between actual and estimated rows that is considered a misestimate
+	rowsMisestimateFactor = 10
+)
+
+const (
+	PlanWarningSeqScan    = "seq_scan"
+	PlanWarningSortSpill  = "sort_spill"
+	PlanWarningNestedLoop = "nested_loop"
+)
+
+var (
+	errInvalidPlan = errors.New("unable to parse query plan")
+
+	ErrAnalyzeNotAllowed         = errors.New("statement cannot be analyzed because it cannot be rolled back")
+	ErrAnalyzeMultipleStatements = errors.New("only a single statement can be analyzed")
+)
+
+type (
+	// ExplainPlan represents a parsed query execution plan
+	ExplainPlan struct {
+		Plan          *PlanNode     `json:"plan"`
+		Analyzed      bool          `json:"analyzed"`
+		RolledBack    bool          `json:"rolled_back"`
+		PlanningTime  float64       `json:"planning_time_ms"`
+		ExecutionTime float64       `json:"execution_time_ms"`
+		Warnings      []PlanWarning `json:"warnings"`
+	}
+
+	// PlanNode represents a single node of the query plan tree
+	PlanNode struct {
+		ID                 int           `json:"id"`
+		NodeType           string        `json:"node_type"`
+		RelationName       string        `json:"relation_name,omitempty"`
+		Schema             string        `json:"schema,omitempty"`
+		Alias              string        `json:"alias,omitempty"`
+		IndexName          string        `json:"index_name,omitempty"`
+		JoinType           string        `json:"join_type,omitempty"`
+		ParentRelationship string        `json:"parent_relationship,omitempty"`
+		Filter             string        `json:"filter,omitempty"`
+		StartupCost        float64       `json:"startup_cost"`
+		TotalCost          float64       `json:"total_cost"`
+		PlanRows           float64       `json:"plan_rows"`
+		PlanWidth          int           `json:"plan_width"`
+		ActualStartupTime  float64       `json:"actual_startup_time_ms"`
+		ActualTotalTime    float64       `json:"actual_total_time_ms"`
+		ActualRows         float64       `json:"actual_rows"`
+		ActualLoops        float64       `json:"actual_loops"`
+		RowsRemoved        float64       `json:"rows_removed_by_filter"`
+		InclusiveTime      float64       `json:"inclusive_time_ms"`
+		ExclusiveTime      float64       `json:"exclusive_time_ms"`
+		RowsEstimateFactor float64       `json:"rows_estimate_factor"`
+		RowsMisestimated   bool          `json:"rows_misestimated"`
+		SortMethod         string        `json:"sort_method,omitempty"`
+		SortSpaceType      string        `json:"sort_space_type,omitempty"`
+		SortSpaceUsed      int64         `json:"sort_space_used_kb,omitempty"`
+		Buffers            PlanBuffers   `json:"buffers"`
+		Warnings           []PlanWarning `json:"warnings"`
+		Plans              []*PlanNode   `json:"plans"`
+	}
+
+	// PlanBuffers contains buffer usage of the plan node, including its children
+	PlanBuffers struct {
+		SharedHit     int64 `json:"shared_hit"`
+		SharedRead    int64 `json:"shared_read"`
+		SharedDirtied int64 `json:"shared_dirtied"`
+		SharedWritten int64 `json:"shared_written"`
+		LocalHit      int64 `json:"local_hit"`
+		LocalRead     int64 `json:"local_read"`
+		TempRead      int64 `json:"temp_read"`
+		TempWritten   int64 `json:"temp_written"`
+	}
+
+	// PlanWarning represents a potential performance issue found in the plan node
+	PlanWarning struct {
+		NodeID  int    `json:"node_id"`
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}
+
+	// explainOutput matches the structure of EXPLAIN (FORMAT JSON) output
+	explainOutput struct {
+		Plan          explainNode `json:"Plan"`
+		PlanningTime  float64     `json:"Planning Time"`
+		ExecutionTime float64     `json:"Execution Time"`
+	}
+
+	explainNode struct {
+		NodeType           string        `json:"Node Type"`
+		RelationName       string        `json:"Relation Name"`
+		Schema             string        `json:"Schema"`
+		Alias              string        `json:"Alias"`
+		IndexName          string        `json:"Index Name"`
+		JoinType           string        `json:"Join Type"`
+		ParentRelationship string        `json:"Parent Relationship"`
+		Filter             string        `json:"Filter"`
+		StartupCost        float64       `json:"Startup Cost"`
+		TotalCost          float64       `json:"Total Cost"`
+		PlanRows           float64       `json:"Plan Rows"`
+		PlanWidth          int           `json:"Plan Width"`
+		ActualStartupTime  float64       `json:"Actual Startup Time"`
+		ActualTotalTime    float64       `json:"Actual Total Time"`
+		ActualRows         float64       `json:"Actual Rows"`
+		ActualLoops        float64       `json:"Actual Loops"`
+		RowsRemoved        float64       `json:"Rows Removed by Filter"`
+		SortMethod         string        `json:"Sort Method"`
+		SortSpaceType      string        `json:"Sort Space Type"`
+		SortSpaceUsed      int64         `json:"Sort Space Used"`
+		SharedHit          int64         `json:"Shared Hit Blocks"`
+		SharedRead         int64         `json:"Shared Read Blocks"`
+		SharedDirtied      int64         `json:"Shared Dirtied Blocks"`
+		SharedWritten      int64         `json:"Shared Written Blocks"`
+		LocalHit           int64         `json:"Local Hit Blocks"`
+		LocalRead          int64         `json:"Local Read Blocks"`
+		TempRead           int64         `json:"Temp Read Blocks"`
+		TempWritten        int64         `json:"Temp Written Blocks"`
+		Plans              []explainNode `json:"Plans"`
+	}
+)
+
+// ExplainQuery returns the parsed execution plan of the query
+func (client *Client) ExplainQuery(query string) (*ExplainPlan, error) {
+	return client.explain("FORMAT JSON", query, false)
+}
+
+// AnalyzeQuery executes the query and returns the parsed execution plan with runtime stats.
+// The query is executed inside of a transaction that is always rolled back.
+func (client *Client) AnalyzeQuery(query string) (*ExplainPlan, error) {
+	return client.explain("ANALYZE, BUFFERS, FORMAT JSON", query, true)
+}
+
+// AnalyzeQueryText returns the text output of EXPLAIN ANALYZE for servers that do not
+// support structured plans. The query is executed inside of a transaction that is always rolled back.
+func (client *Client) AnalyzeQueryText(query string) (*Result, error) {
+	if err := checkAnalyzeQuery(query); err != nil {
+		return nil, err
+	}
+	return client.queryWithRollback("EXPLAIN ANALYZE " + query)
+}
+
+func (client *Client) explain(options string, query string, analyze bool, args ...interface{}) (*ExplainPlan, error) {
+	if client.serverType == cockroachType {
+		return nil, ErrNotSupported
+	}
+
+	// Functions called by the query could modify data too, so analyzed statements
+	// are always executed inside of a transaction that is rolled back
+	run := client.query
+	if analyze {
+		if err := checkAnalyzeQuery(query); err != nil {
+			return nil, err
+		}
+		run = client.queryWithRollback
+	}
+
+	res, err := run(fmt.Sprintf("EXPLAIN (%s) %s", options, query), args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Rows) == 0 || len(res.Rows[0]) == 0 {
+		return nil, errInvalidPlan
+	}
+
+	data, ok := res.Rows[0][0].(string)
+	if !ok {
+		return nil, errInvalidPlan
+	}
+
+	plan, err := parseExplainPlan([]byte(data), analyze)
+	if err != nil {
+		return nil, err
+	}
+	plan.RolledBack = analyze
+
+	return plan, nil
+}
+
+// checkAnalyzeQuery rejects statements that can not be analyzed inside of a transaction
+func checkAnalyzeQuery(query string) error {
+	statements, err := parser.Parse(query)
+	if err != nil {
+		return err
+	}
+
+	// Additional statements would be executed outside of the explain and could end the transaction
+	if len(statements) != 1 {
+		return ErrAnalyzeMultipleStatements
+	}
+
+	if !statements[0].Transactional {
+		return ErrAnalyzeNotAllowed
+	}
+
+	return nil
+}
+
+// parseExplainPlan builds the plan tree from EXPLAIN (FORMAT JSON) output
+func parseExplainPlan(data []byte, analyze bool) (*ExplainPlan, error) {
+	output := []explainOutput{}
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidPlan, err)
+	}
+	if len(output) == 0 {
+		return nil, errInvalidPlan
+	}
+
+	plan := &ExplainPlan{
+		Analyzed:      analyze,
+		PlanningTime:  output[0].PlanningTime,
+		ExecutionTime: output[0].ExecutionTime,
+		Warnings:      []PlanWarning{},
+	}
+
+	nextID := 0
+	plan.Plan = buildPlanNode(output[0].Plan, analyze, &nextID)
+
+	plan.Plan.walk(func(node *PlanNode) {
+		plan.Warnings = append(plan.Warnings, node.Warnings...)
+	})
+
+	return plan, nil
+}
+
+func buildPlanNode(src explainNode, analyze bool, nextID *int) *PlanNode {
+	node := &PlanNode{
+		ID:                 *nextID,
+		NodeType:           src.NodeType,
+		RelationName:       src.RelationName,
+		Schema:             src.Schema,
+		Alias:              src.Alias,
+		IndexName:          src.IndexName,
+		JoinType:           src.JoinType,
+		ParentRelationship: src.ParentRelationship,
+		Filter:             src.Filter,
+		StartupCost:        src.StartupCost,
+		TotalCost:          src.TotalCost,
+		PlanRows:           src.PlanRows,
+		PlanWidth:          src.PlanWidth,
+		ActualStartupTime:  src.ActualStartupTime,
+		ActualTotalTime:    src.ActualTotalTime,
+		ActualRows:         src.ActualRows,
+		ActualLoops:        src.ActualLoops,
+		RowsRemoved:        src.RowsRemoved,
+		SortMethod:         src.SortMethod,
+		SortSpaceType:      src.SortSpaceType,
+		SortSpaceUsed:      src.SortSpaceUsed,
+		Buffers: PlanBuffers{
+			SharedHit:     src.SharedHit,
+			SharedRead:    src.SharedRead,
+			SharedDirtied: src.SharedDirtied,
+			SharedWritten: src.SharedWritten,
+			LocalHit:      src.LocalHit,
+			LocalRead:     src.LocalRead,
+			TempRead:      src.TempRead,
+			TempWritten:   src.TempWritten,
+		},
+		Warnings: []PlanWarning{},
+		Plans:    []*PlanNode{},
+	}
+	*nextID++
+
+	for _, child := range src.Plans {
+		node.Plans = append(node.Plans, buildPlanNode(child, analyze, nextID))
+	}
+
+	if analyze {
+		node.calculateTiming()
+		node.calculateRowsEstimate()
+	}
+	node.detectWarnings(analyze)
+
+	return node
+}
+
+// walk calls the function for the node and all its children, depth first
+func (node *PlanNode) walk(fn func(*PlanNode)) {
+	fn(node)
+	for _, child := range node.Plans {
+		child.walk(fn)
+	}
+}
+
+// totalRows returns the number of rows produced by the node across all loops
+func (node *PlanNode) totalRows(analyze bool) float64 {
+	if analyze {
+		return node.ActualRows * node.ActualLoops
+	}
+	return node.PlanRows
+}
+
+// calculateTiming sets the time spent in the node itself, excluding the children.
+// Actual time is reported per loop, so it has to be multiplied by number of loops.
+func (node *PlanNode) calculateTiming() {
+	node.InclusiveTime = node.ActualTotalTime * node.ActualLoops
+	node.ExclusiveTime = node.InclusiveTime
+
+	for _, child := range node.Plans {
+		// Subplans are evaluated within the parent node, so their time is included into the
+		// parent time. Init plans are executed once on demand, not necessarily by the parent.
+		if child.ParentRelationship == "InitPlan" {
+			continue
+		}
+		node.ExclusiveTime -= child.InclusiveTime
+	}
+
+	if node.ExclusiveTime < 0 {
+		node.ExclusiveTime = 0
+	}
+}
+
+// calculateRowsEstimate compares the planner estimate with the actual number of rows
+func (node *PlanNode) calculateRowsEstimate() {
+	if node.ActualLoops == 0 {
+		return
+	}
+
+	actual := max(node.ActualRows, 1)
+	planned := max(node.PlanRows, 1)
+
+	node.RowsEstimateFactor = actual / planned
+	node.RowsMisestimated = node.RowsEstimateFactor >= rowsMisestimateFactor ||
+		node.RowsEstimateFactor <= 1.0/rowsMisestimateFactor
+}
+
+func (node *PlanNode) detectWarnings(analyze bool) {
+	switch node.NodeType {
+	case "Seq Scan":
+		scanned := node.totalRows(analyze) + node.RowsRemoved*max(node.ActualLoops, 1)
+		if scanned >= seqScanRowsThreshold {
+			node.addWarning(PlanWarningSeqScan, "Sequential scan on large table %s (%.0f rows scanned)", node.RelationName, scanned)
+		}
+	case "Sort":
+		if node.SortSpaceType == "Disk" {
+			node.addWarning(PlanWarningSortSpill, "Sort spilled to disk (%d kB used)", node.SortSpaceUsed)
+		}
+	case "Nested Loop":
+		if len(node.Plans) > 0 {
+			outer := node.Plans[0].totalRows(analyze)
+			if outer >= nestedLoopRowsThreshold {
+				node.addWarning(PlanWarningNestedLoop, "Nested loop with large outer input (%.0f rows)", outer)
+			}
+		}
+	}
+}
+
+func (node *PlanNode) addWarning(kind string, format string, args ...interface{}) {
+	node.Warnings = append(node.Warnings, PlanWarning{
+		NodeID:  node.ID,
+		Type:    kind,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const examplePlan = `[
+  {
+    "Plan": {
+      "Node Type": "Nested Loop",
+      "Join Type": "Inner",
+      "Startup Cost": 0.29,
+      "Total Cost": 2500.5,
+      "Plan Rows": 10,
+      "Plan Width": 16,
+      "Actual Startup Time": 0.05,
+      "Actual Total Time": 150.0,
+      "Actual Rows": 1500,
+      "Actual Loops": 1,
+      "Shared Hit Blocks": 120,
+      "Shared Read Blocks": 30,
+      "Plans": [
+        {
+          "Node Type": "Seq Scan",
+          "Parent Relationship": "Outer",
+          "Relation Name": "events",
+          "Alias": "e",
+          "Startup Cost": 0.0,
+          "Total Cost": 1800.0,
+          "Plan Rows": 10,
+          "Plan Width": 8,
+          "Actual Startup Time": 0.01,
+          "Actual Total Time": 80.0,
+          "Actual Rows": 20000,
+          "Actual Loops": 1,
+          "Filter": "(kind = 1)",
+          "Rows Removed by Filter": 90000,
+          "Shared Hit Blocks": 100,
+          "Shared Read Blocks": 30
+        },
+        {
+          "Node Type": "Sort",
+          "Parent Relationship": "Inner",
+          "Startup Cost": 0.29,
+          "Total Cost": 0.5,
+          "Plan Rows": 1,
+          "Plan Width": 8,
+          "Actual Startup Time": 0.002,
+          "Actual Total Time": 0.003,
+          "Actual Rows": 0,
+          "Actual Loops": 20000,
+          "Sort Method": "external merge",
+          "Sort Space Type": "Disk",
+          "Sort Space Used": 2048,
+          "Shared Hit Blocks": 20
+        }
+      ]
+    },
+    "Planning Time": 0.25,
+    "Execution Time": 151.5
+  }
+]`
+
+func TestParseExplainPlan(t *testing.T) {
+	t.Run("invalid input", func(t *testing.T) {
+		_, err := parseExplainPlan([]byte("QUERY PLAN"), false)
+		assert.ErrorIs(t, err, errInvalidPlan)
+
+		_, err = parseExplainPlan([]byte("[]"), false)
+		assert.Equal(t, errInvalidPlan, err)
+	})
+
+	t.Run("analyzed plan", func(t *testing.T) {
+		plan, err := parseExplainPlan([]byte(examplePlan), true)
+		require.NoError(t, err)
+
+		assert.True(t, plan.Analyzed)
+		assert.Equal(t, 0.25, plan.PlanningTime)
+		assert.Equal(t, 151.5, plan.ExecutionTime)
+
+		root := plan.Plan
+		require.Len(t, root.Plans, 2)
+		assert.Equal(t, 0, root.ID)
+		assert.Equal(t, "Nested Loop", root.NodeType)
+		assert.Equal(t, int64(120), root.Buffers.SharedHit)
+		assert.Equal(t, 150.0, root.InclusiveTime)
+		assert.InDelta(t, 10.0, root.ExclusiveTime, 0.0001)
+		assert.Equal(t, 150.0, root.RowsEstimateFactor)
+		assert.True(t, root.RowsMisestimated)
+
+		scan := root.Plans[0]
+		assert.Equal(t, 1, scan.ID)
+		assert.Equal(t, "events", scan.RelationName)
+		assert.Equal(t, 80.0, scan.ExclusiveTime)
+		assert.Equal(t, 90000.0, scan.RowsRemoved)
+
+		sort := root.Plans[1]
+		assert.Equal(t, 2, sort.ID)
+		assert.InDelta(t, 60.0, sort.InclusiveTime, 0.0001)
+		assert.Equal(t, 1.0, sort.RowsEstimateFactor)
+		assert.False(t, sort.RowsMisestimated)
+
+		assert.Equal(t, []PlanWarning{
+			{NodeID: 0, Type: PlanWarningNestedLoop, Message: "Nested loop with large outer input (20000 rows)"},
+			{NodeID: 1, Type: PlanWarningSeqScan, Message: "Sequential scan on large table events (110000 rows scanned)"},
+			{NodeID: 2, Type: PlanWarningSortSpill, Message: "Sort spilled to disk (2048 kB used)"},
+		}, plan.Warnings)
+	})
+
+	t.Run("estimated plan", func(t *testing.T) {
+		plan, err := parseExplainPlan([]byte(examplePlan), false)
+		require.NoError(t, err)
+
+		assert.False(t, plan.Analyzed)
+		assert.Equal(t, 0.0, plan.Plan.ExclusiveTime)
+		assert.Equal(t, 0.0, plan.Plan.RowsEstimateFactor)
+
+		// Planner estimates are too low to trigger scan and loop warnings
+		assert.Equal(t, []PlanWarning{
+			{NodeID: 2, Type: PlanWarningSortSpill, Message: "Sort spilled to disk (2048 kB used)"},
+		}, plan.Warnings)
+	})
+}
+
+func TestPlanNodeCalculateTiming(t *testing.T) {
+	node := &PlanNode{
+		ActualTotalTime: 100,
+		ActualLoops:     1,
+		Plans: []*PlanNode{
+			{ParentRelationship: "InitPlan", InclusiveTime: 30},
+			{ParentRelationship: "SubPlan", InclusiveTime: 40},
+			{ParentRelationship: "Outer", InclusiveTime: 20},
+		},
+	}
+	node.calculateTiming()
+
+	// Subplan time is included into the parent time, init plan time is not
+	assert.Equal(t, 100.0, node.InclusiveTime)
+	assert.Equal(t, 40.0, node.ExclusiveTime)
+}
+
+func TestCheckAnalyzeQuery(t *testing.T) {
+	examples := []struct {
+		query string
+		err   error
+	}{
+		{query: "SELECT * FROM books"},
+		{query: "SELECT * FROM books;  "},
+		{query: "-- comment\nSELECT 1"},
+		{query: "UPDATE books SET title = 'foo'"},
+		{query: "insert into books (id) values (1)"},
+		{query: "DELETE FROM books"},
+		{query: "MERGE INTO books USING authors ON true WHEN MATCHED THEN DO NOTHING"},
+		{query: "WITH deleted AS (DELETE FROM books RETURNING *) SELECT * FROM deleted"},
+		{query: "SELECT * INTO books_copy FROM books"},
+		{query: "CREATE TABLE books_copy AS SELECT * FROM books"},
+		{query: "/* comment */ UPDATE books SET title = 'foo'"},
+		{query: "VACUUM books", err: ErrAnalyzeNotAllowed},
+		{query: "vacuum analyze books", err: ErrAnalyzeNotAllowed},
+		{query: "CREATE INDEX CONCURRENTLY books_idx ON books (title)", err: ErrAnalyzeNotAllowed},
+		{query: "create unique index concurrently books_idx ON books (title)", err: ErrAnalyzeNotAllowed},
+		{query: "DROP INDEX CONCURRENTLY books_idx", err: ErrAnalyzeNotAllowed},
+		{query: "REINDEX TABLE CONCURRENTLY books", err: ErrAnalyzeNotAllowed},
+		{query: "CREATE DATABASE foo", err: ErrAnalyzeNotAllowed},
+		{query: "SELECT 1; DELETE FROM books", err: ErrAnalyzeMultipleStatements},
+		{query: "UPDATE books SET title = 'foo'; COMMIT", err: ErrAnalyzeMultipleStatements},
+	}
+
+	for _, ex := range examples {
+		t.Run(ex.query, func(t *testing.T) {
+			assert.Equal(t, ex.err, checkAnalyzeQuery(ex.query))
+		})
+	}
+}
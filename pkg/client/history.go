@@ -0,0 +1,66 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sosedoff/pgweb/pkg/command"
+	"github.com/sosedoff/pgweb/pkg/history"
+)
+
+// defaultHistoryStore is shared by all clients, when not set each client keeps
+// its own history in memory.
+var defaultHistoryStore history.Store
+
+// SetDefaultHistoryStore configures the query history store for new clients
+func SetDefaultHistoryStore(store history.Store) {
+	defaultHistoryStore = store
+}
+
+// History returns the query history records of the client connection
+func (client *Client) History(filter history.Filter) ([]history.Record, int, error) {
+	filter.Connection = client.connectionID
+	return client.historyStore().Find(filter)
+}
+
+// DeleteHistoryRecord removes a single query history record of the client connection
+func (client *Client) DeleteHistoryRecord(id string) error {
+	return client.historyStore().Delete(client.connectionID, id)
+}
+
+// ClearHistory removes all query history records of the client connection
+func (client *Client) ClearHistory() error {
+	return client.historyStore().Clear(client.connectionID)
+}
+
+func (client *Client) historyStore() history.Store {
+	client.historyOnce.Do(func() {
+		if client.history == nil {
+			client.history = defaultHistoryStore
+		}
+		if client.history == nil {
+			client.history = history.NewMemoryStore(int(command.Opts.HistoryLimit))
+		}
+	})
+	return client.history
+}
+
+func (client *Client) addHistoryRecord(query string, start time.Time, result *Result, err error) {
+	record := history.NewRecord(query)
+	record.Connection = client.connectionID
+	record.Timestamp = start
+	record.Duration = time.Since(start).Milliseconds()
+	record.Success = err == nil
+
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if result != nil {
+		record.RowsCount = len(result.Rows)
+	}
+
+	if err := client.historyStore().Add(record); err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] unable to save query history record: %v\n", err)
+	}
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sosedoff/pgweb/pkg/parser"
+)
+
+var (
+	ErrQueryCostExceeded = errors.New("query exceeds the configured cost limits")
+	ErrQueryCostUnknown  = errors.New("unable to estimate query cost")
+
+	// Statement types that could be explained to get planner estimates
+	explainableTypes = map[string]bool{
+		"select": true,
+		"insert": true,
+		"update": true,
+		"delete": true,
+		"merge":  true,
+	}
+)
+
+// QueryCostEstimate contains planner estimates of the query and configured limits
+type QueryCostEstimate struct {
+	Cost     float64 `json:"cost"`
+	Rows     float64 `json:"rows"`
+	MaxCost  float64 `json:"max_cost,omitempty"`
+	MaxRows  int64   `json:"max_rows,omitempty"`
+	Exceeded bool    `json:"exceeded"`
+}
+
+// SetQueryCostLimits sets the planner estimated cost and rows limits of ad-hoc queries.
+// Zero value disables the corresponding limit.
+func (client *Client) SetQueryCostLimits(maxCost float64, maxRows int64) {
+	client.maxQueryCost = maxCost
+	client.maxQueryRows = maxRows
+}
+
+// HasQueryCostLimits returns true if any of the query cost limits is configured
+func (client *Client) HasQueryCostLimits() bool {
+	return client.maxQueryCost > 0 || client.maxQueryRows > 0
+}
+
+// EstimateQueryCost returns planner estimates for all statements of the query.
+// Query arguments are only supported for single statement queries. Statements
+// that could not be explained fail the estimate, so the cost is never unknown.
+func (client *Client) EstimateQueryCost(query string, args ...interface{}) (*QueryCostEstimate, error) {
+	if client.serverType == cockroachType {
+		return nil, ErrNotSupported
+	}
+
+	statements, err := parser.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 0 && len(statements) > 1 {
+		return nil, errors.New("query arguments are not supported for multiple statements")
+	}
+
+	estimate := &QueryCostEstimate{
+		MaxCost: client.maxQueryCost,
+		MaxRows: client.maxQueryRows,
+	}
+
+	for _, stmt := range statements {
+		if !explainableTypes[stmt.Type] {
+			continue
+		}
+
+		plan, err := client.explain("FORMAT JSON", stmt.Query, false, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		estimate.Cost += plan.Plan.TotalCost
+		estimate.Rows += plan.Plan.PlanRows
+	}
+
+	estimate.Exceeded = (estimate.MaxCost > 0 && estimate.Cost > estimate.MaxCost) ||
+		(estimate.MaxRows > 0 && estimate.Rows > float64(estimate.MaxRows))
+
+	return estimate, nil
+}
+
+// CheckQueryCost returns an error when planner estimates of the query exceed configured limits
+// or when the query cost could not be estimated
+func (client *Client) CheckQueryCost(query string, args ...interface{}) (*QueryCostEstimate, error) {
+	if !client.HasQueryCostLimits() {
+		return nil, nil
+	}
+
+	estimate, err := client.EstimateQueryCost(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrQueryCostUnknown, err)
+	}
+	if estimate.Exceeded {
+		return estimate, fmt.Errorf("%w: estimated cost %.0f, rows %.0f", ErrQueryCostExceeded, estimate.Cost, estimate.Rows)
+	}
+
+	return estimate, nil
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sosedoff/pgweb/pkg/statements"
+)
+
+var (
+	// Columns allowed for sorting of query stats
+	queryStatsSortColumns = map[string]string{
+		"total_time":      "total_time_ms",
+		"mean_time":       "mean_time_ms",
+		"calls":           "calls",
+		"rows":            "rows",
+		"shared_blks_hit": "shared_blks_hit",
+	}
+)
+
+// QueryStatsAvailable returns true if pg_stat_statements extension is installed
+func (client *Client) QueryStatsAvailable() (bool, error) {
+	sql, err := client.queryStatsStatement()
+	return sql != "", err
+}
+
+// QueryStats returns normalized queries stats collected by pg_stat_statements extension
+func (client *Client) QueryStats(opts QueryStatsOptions) (*Result, error) {
+	sql, err := client.queryStatsStatement()
+	if err != nil {
+		return nil, err
+	}
+	if sql == "" {
+		return nil, ErrQueryStatsDisabled
+	}
+
+	sortColumn := queryStatsSortColumns["total_time"]
+	if opts.SortColumn != "" {
+		sortColumn = queryStatsSortColumns[opts.SortColumn]
+		if sortColumn == "" {
+			return nil, fmt.Errorf("invalid sort column: %q", opts.SortColumn)
+		}
+	}
+
+	sortOrder := strings.ToUpper(opts.SortOrder)
+	if sortOrder == "" {
+		sortOrder = "DESC"
+	}
+	if sortOrder != "ASC" && sortOrder != "DESC" {
+		return nil, fmt.Errorf("invalid sort order: %q", opts.SortOrder)
+	}
+
+	filters := []string{}
+	args := []interface{}{}
+
+	if opts.User != "" {
+		args = append(args, opts.User)
+		filters = append(filters, fmt.Sprintf("pg_get_userbyid(s.userid) = $%d", len(args)))
+	}
+	if opts.Database != "" {
+		args = append(args, opts.Database)
+		filters = append(filters, fmt.Sprintf("d.datname = $%d", len(args)))
+	}
+
+	if len(filters) > 0 {
+		sql += " WHERE " + strings.Join(filters, " AND ")
+	}
+
+	sql += fmt.Sprintf(" ORDER BY %s %s NULLS LAST", sortColumn, sortOrder)
+
+	if opts.Limit > 0 {
+		sql += fmt.Sprintf(" LIMIT %d", opts.Limit)
+	}
+
+	return client.query(sql, args...)
+}
+
+// ResetQueryStats discards all statistics collected by pg_stat_statements extension
+func (client *Client) ResetQueryStats() error {
+	if client.IsReadOnly() {
+		return ErrReadOnlyMode
+	}
+
+	available, err := client.QueryStatsAvailable()
+	if err != nil {
+		return err
+	}
+	if !available {
+		return ErrQueryStatsDisabled
+	}
+
+	_, err = client.query("SELECT pg_stat_statements_reset()")
+	return err
+}
+
+// queryStatsStatement returns the query stats statement for the installed version of
+// pg_stat_statements extension, empty if the extension is not installed or not supported.
+// Extension could be left un-upgraded after the server upgrade, so server version is not used.
+func (client *Client) queryStatsStatement() (string, error) {
+	if client.serverType == cockroachType {
+		return "", nil
+	}
+
+	res, err := client.query(statements.ExtensionVersion, "pg_stat_statements")
+	if err != nil {
+		return "", err
+	}
+
+	version, _ := res.Rows[0][0].(string)
+	return queryStatsStatementForVersion(version), nil
+}
+
+// queryStatsStatementForVersion returns the statement for pg_stat_statements version:
+// query ids are available since 1.2, mean/min/max timing since 1.3, timing columns were renamed in 1.8
+func queryStatsStatementForVersion(version string) string {
+	if version == "" {
+		return ""
+	}
+
+	major, minor := getMajorMinorVersion(version)
+	switch {
+	case major > 1 || minor >= 8:
+		return statements.QueryStats
+	case minor >= 3:
+		return statements.QueryStatsLegacy
+	case minor == 2:
+		return statements.QueryStatsBasic
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sosedoff/pgweb/pkg/statements"
+)
+
+func TestQueryStatsStatementForVersion(t *testing.T) {
+	examples := map[string]string{
+		"":     "",
+		"1.1":  "",
+		"1.2":  statements.QueryStatsBasic,
+		"1.3":  statements.QueryStatsLegacy,
+		"1.7":  statements.QueryStatsLegacy,
+		"1.8":  statements.QueryStats,
+		"1.11": statements.QueryStats,
+		"2.0":  statements.QueryStats,
+	}
+
+	for version, expected := range examples {
+		t.Run(version, func(t *testing.T) {
+			assert.Equal(t, expected, queryStatsStatementForVersion(version))
+		})
+	}
+}
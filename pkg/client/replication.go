@@ -0,0 +1,70 @@
+package client
+
+import (
+	"github.com/sosedoff/pgweb/pkg/statements"
+)
+
+const (
+	ServerRolePrimary = "primary"
+	ServerRoleStandby = "standby"
+)
+
+// ServerRole returns the replication role of the server, primary or standby
+func (client *Client) ServerRole() (string, error) {
+	if client.serverType == cockroachType {
+		return "", ErrNotSupported
+	}
+
+	res, err := client.query("SELECT pg_is_in_recovery()")
+	if err != nil {
+		return "", err
+	}
+
+	if inRecovery, _ := res.Rows[0][0].(bool); inRecovery {
+		return ServerRoleStandby, nil
+	}
+	return ServerRolePrimary, nil
+}
+
+// RecoveryStatus returns the server recovery status and the replay lag on standby servers
+func (client *Client) RecoveryStatus() (*Result, error) {
+	return client.replicationQuery(statements.RecoveryStatus)
+}
+
+// Replicas returns streaming replication stats for all connected standby servers
+func (client *Client) Replicas() (*Result, error) {
+	return client.replicationQuery(statements.Replicas)
+}
+
+// ReplicationSlots returns all replication slots with amount of retained WAL
+func (client *Client) ReplicationSlots() (*Result, error) {
+	return client.replicationQuery(statements.ReplicationSlots)
+}
+
+// WalReceiver returns the WAL receiver status on standby servers
+func (client *Client) WalReceiver() (*Result, error) {
+	return client.replicationQuery(statements.WalReceiver)
+}
+
+// Publications returns all logical replication publications in the current database
+func (client *Client) Publications() (*Result, error) {
+	return client.replicationQuery(statements.Publications)
+}
+
+// Subscriptions returns logical replication subscriptions stats
+func (client *Client) Subscriptions() (*Result, error) {
+	return client.replicationQuery(statements.Subscriptions)
+}
+
+func (client *Client) replicationQuery(queries map[string]string) (*Result, error) {
+	if client.serverType == cockroachType {
+		return nil, ErrNotSupported
+	}
+
+	query := getVersionedStatement(queries, client.serverVersion)
+	if query == "" {
+		return nil, ErrNotSupported
+	}
+
+	return client.query(query)
+}
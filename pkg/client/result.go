@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/sosedoff/pgweb/pkg/chart"
 	"github.com/sosedoff/pgweb/pkg/command"
 )
 
@@ -34,6 +35,15 @@ type (
 		SortOrder  string // Sort direction (ASC, DESC)
 	}
 
+	// QueryStatsOptions contains a list of parameters for query stats requests
+	QueryStatsOptions struct {
+		User       string // Filter by user name
+		Database   string // Filter by database name
+		SortColumn string // Column to sort by
+		SortOrder  string // Sort direction (ASC, DESC)
+		Limit      int    // Number of rows to fetch
+	}
+
 	Pagination struct {
 		Rows    int64 `json:"rows_count"`
 		Page    int64 `json:"page"`
@@ -46,17 +56,36 @@ type (
 		Columns    []string     `json:"columns"`
 		Rows       []Row        `json:"rows"`
 		Stats      *ResultStats `json:"stats,omitempty"`
+		Chart      *chart.Data  `json:"chart,omitempty"`
 	}
 
 	ResultStats struct {
 		ColumnsCount    int       `json:"columns_count"`
 		RowsCount       int       `json:"rows_count"`
 		RowsAffected    int64     `json:"rows_affected"`
+		Truncated       bool      `json:"truncated"`
 		QueryStartTime  time.Time `json:"query_start_time"`
 		QueryFinishTime time.Time `json:"query_finish_time"`
 		QueryDuration   int64     `json:"query_duration_ms"`
 	}
 
+	// IndexDiagnostics contains index usage reports, each row comes with a suggested SQL
+	IndexDiagnostics struct {
+		Unused    *Result `json:"unused"`
+		Duplicate *Result `json:"duplicate"`
+		Invalid   *Result `json:"invalid"`
+		MissingFK *Result `json:"missing_fk_indexes"`
+		SeqScans  *Result `json:"seq_scans"`
+	}
+
+	// BloatReport contains wasted space estimates for tables and indexes
+	BloatReport struct {
+		Tables           *Result `json:"tables"`
+		Indexes          *Result `json:"indexes"`
+		Pgstattuple      bool    `json:"pgstattuple"`
+		PgstattupleError string  `json:"pgstattuple_error,omitempty"`
+	}
+
 	Object struct {
 		OID  string `json:"oid"`
 		Name string `json:"name"`
@@ -7,13 +7,6 @@ import (
 )
 
 var (
-	// List of keywords that are not allowed in read-only mode
-	reRestrictedKeywords = regexp.MustCompile(`(?mi)\s?(CREATE|INSERT|UPDATE|DROP|DELETE|TRUNCATE|GRANT|OPEN|IMPORT|COPY)\s`)
-
-	// Comment regular expressions
-	reSlashComment = regexp.MustCompile(`(?m)/\*.+\*/`)
-	reDashComment  = regexp.MustCompile(`(?m)--.+`)
-
 	// Postgres version signature
 	postgresSignature     = regexp.MustCompile(`(?i)postgresql ([\d\.]+)\s?`)
 	postgresDumpSignature = regexp.MustCompile(`\s([\d\.]+)\s?`)
@@ -42,6 +35,27 @@ func getMajorMinorVersionString(str string) string {
 	return fmt.Sprintf("%d.%d", major, minor)
 }
 
+// getVersionedStatement returns a statement defined for the given server version.
+// Lookup is performed by major and minor version first, then by major version only,
+// and falls back to the default statement.
+// Example: 9.6.1 -> 9.6, 12.3 -> 12.3 or 12
+func getVersionedStatement[T any](items map[string]T, version string) T {
+	major, _ := getMajorMinorVersion(version)
+
+	keys := []string{
+		getMajorMinorVersionString(version),
+		fmt.Sprintf("%d", major),
+	}
+
+	for _, key := range keys {
+		if val, ok := items[key]; ok {
+			return val
+		}
+	}
+
+	return items["default"]
+}
+
 func detectServerTypeAndVersion(version string) (bool, string, string) {
 	version = strings.TrimSpace(version)
 
@@ -80,14 +94,6 @@ func checkVersionRequirement(client, server string) bool {
 	return clientMajor >= serverMajor
 }
 
-// containsRestrictedKeywords returns true if given keyword is not allowed in read-only mode
-func containsRestrictedKeywords(str string) bool {
-	str = reSlashComment.ReplaceAllString(str, "")
-	str = reDashComment.ReplaceAllString(str, "")
-
-	return reRestrictedKeywords.MatchString(str)
-}
-
 func hasBinary(data string, checkLen int) bool {
 	for idx, chr := range data {
 		if int(chr) < 32 || int(chr) > 126 {
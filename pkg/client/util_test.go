@@ -95,6 +95,30 @@ func TestGetMajorMinorVersion(t *testing.T) {
 	}
 }
 
+func TestGetVersionedStatement(t *testing.T) {
+	statements := map[string]string{
+		"default": "default",
+		"9.6":     "9.6",
+		"12":      "12",
+	}
+
+	examples := map[string]string{
+		"":       "default",
+		"9.5":    "default",
+		"9.6":    "9.6",
+		"9.6.24": "9.6",
+		"12":     "12",
+		"12.1":   "12",
+		"16.2":   "default",
+	}
+
+	for version, expected := range examples {
+		t.Run(version, func(t *testing.T) {
+			assert.Equal(t, expected, getVersionedStatement(statements, version))
+		})
+	}
+}
+
 func TestCheckVersionRequirement(t *testing.T) {
 	examples := []struct {
 		client string
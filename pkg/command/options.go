@@ -20,52 +20,76 @@ const (
 )
 
 type Options struct {
-	Version                      bool   `short:"v" long:"version" description:"Print version"`
-	Debug                        bool   `short:"d" long:"debug" description:"Enable debugging mode"`
-	LogLevel                     string `long:"log-level" description:"Logging level" default:"info"`
-	LogFormat                    string `long:"log-format" description:"Logging output format" default:"text"`
-	LogForwardedUser             bool   `long:"log-forwarded-user" description:"Log user information available in X-Forwarded-User/Email headers"`
-	URL                          string `long:"url" description:"Database connection string"`
-	Host                         string `long:"host" description:"Server hostname or IP" default:"localhost"`
-	Port                         int    `long:"port" description:"Server port" default:"5432"`
-	User                         string `long:"user" description:"Database user"`
-	Pass                         string `long:"pass" description:"Password for user"`
-	Passfile                     string `long:"passfile" description:"Local passwords file location"`
-	DbName                       string `long:"db" description:"Database name"`
-	SSLMode                      string `long:"ssl" description:"SSL mode"`
-	SSLRootCert                  string `long:"ssl-rootcert" description:"SSL certificate authority file"`
-	SSLCert                      string `long:"ssl-cert" description:"SSL client certificate file"`
-	SSLKey                       string `long:"ssl-key" description:"SSL client certificate key file"`
-	OpenTimeout                  int    `long:"open-timeout" description:"Maximum wait time for connection, in seconds" default:"30"`
-	RetryDelay                   uint   `long:"open-retry-delay" description:"Number of seconds to wait before retrying the connection" default:"3"`
-	RetryCount                   uint   `long:"open-retry" description:"Number of times to retry establishing connection" default:"0"`
-	HTTPHost                     string `long:"bind" description:"HTTP server host" default:"localhost"`
-	HTTPPort                     uint   `long:"listen" description:"HTTP server listen port" default:"8081"`
-	AuthUser                     string `long:"auth-user" description:"HTTP basic auth user"`
-	AuthPass                     string `long:"auth-pass" description:"HTTP basic auth password"`
-	SkipOpen                     bool   `short:"s" long:"skip-open" description:"Skip browser open on start"`
-	Sessions                     bool   `long:"sessions" description:"Enable multiple database sessions"`
-	Prefix                       string `long:"prefix" description:"Add a url prefix"`
-	ReadOnly                     bool   `long:"readonly" description:"Run database connection in readonly mode"`
-	LockSession                  bool   `long:"lock-session" description:"Lock session to a single database connection"`
-	Bookmark                     string `short:"b" long:"bookmark" description:"Bookmark to use for connection. Bookmark files are stored under $HOME/.pgweb/bookmarks/*.toml" default:""`
-	BookmarksDir                 string `long:"bookmarks-dir" description:"Overrides default directory for bookmark files to search" default:""`
-	BookmarksOnly                bool   `long:"bookmarks-only" description:"Allow only connections from bookmarks"`
-	QueriesDir                   string `long:"queries-dir" description:"Overrides default directory for local queries"`
-	DisablePrettyJSON            bool   `long:"no-pretty-json" description:"Disable JSON formatting feature for result export"`
-	DisableSSH                   bool   `long:"no-ssh" description:"Disable database connections via SSH"`
-	ConnectBackend               string `long:"connect-backend" description:"Enable database authentication through a third party backend"`
-	ConnectToken                 string `long:"connect-token" description:"Authentication token for the third-party connect backend"`
-	ConnectHeaders               string `long:"connect-headers" description:"List of headers to pass to the connect backend"`
-	DisableConnectionIdleTimeout bool   `long:"no-idle-timeout" description:"Disable connection idle timeout"`
-	ConnectionIdleTimeout        int    `long:"idle-timeout" description:"Set connection idle timeout in minutes" default:"180"`
-	QueryTimeout                 uint   `long:"query-timeout" description:"Set global query execution timeout in seconds" default:"300"`
-	Cors                         bool   `long:"cors" description:"Enable Cross-Origin Resource Sharing (CORS)"`
-	CorsOrigin                   string `long:"cors-origin" description:"Allowed CORS origins" default:"*"`
-	BinaryCodec                  string `long:"binary-codec" description:"Codec for binary data serialization, one of 'none', 'hex', 'base58', 'base64'" default:"none"`
-	MetricsEnabled               bool   `long:"metrics" description:"Enable Prometheus metrics endpoint"`
-	MetricsPath                  string `long:"metrics-path" description:"Path prefix for Prometheus metrics endpoint" default:"/metrics"`
-	MetricsAddr                  string `long:"metrics-addr" description:"Listen host and port for Prometheus metrics server"`
+	Version                      bool    `short:"v" long:"version" description:"Print version"`
+	Debug                        bool    `short:"d" long:"debug" description:"Enable debugging mode"`
+	LogLevel                     string  `long:"log-level" description:"Logging level" default:"info"`
+	LogFormat                    string  `long:"log-format" description:"Logging output format" default:"text"`
+	LogForwardedUser             bool    `long:"log-forwarded-user" description:"Log user information available in X-Forwarded-User/Email headers"`
+	URL                          string  `long:"url" description:"Database connection string"`
+	Service                      string  `long:"service" description:"Connection service name defined in the pg_service.conf file"`
+	Host                         string  `long:"host" description:"Server hostname or IP" default:"localhost"`
+	Port                         int     `long:"port" description:"Server port" default:"5432"`
+	User                         string  `long:"user" description:"Database user"`
+	Pass                         string  `long:"pass" description:"Password for user"`
+	Passfile                     string  `long:"passfile" description:"Local passwords file location"`
+	DbName                       string  `long:"db" description:"Database name"`
+	SSLMode                      string  `long:"ssl" description:"SSL mode"`
+	SSLRootCert                  string  `long:"ssl-rootcert" description:"SSL certificate authority file"`
+	SSLCert                      string  `long:"ssl-cert" description:"SSL client certificate file"`
+	SSLKey                       string  `long:"ssl-key" description:"SSL client certificate key file"`
+	OpenTimeout                  int     `long:"open-timeout" description:"Maximum wait time for connection, in seconds" default:"30"`
+	RetryDelay                   uint    `long:"open-retry-delay" description:"Number of seconds to wait before retrying the connection" default:"3"`
+	RetryCount                   uint    `long:"open-retry" description:"Number of times to retry establishing connection" default:"0"`
+	HTTPHost                     string  `long:"bind" description:"HTTP server host" default:"localhost"`
+	HTTPPort                     uint    `long:"listen" description:"HTTP server listen port" default:"8081"`
+	AuthUser                     string  `long:"auth-user" description:"HTTP basic auth user"`
+	AuthPass                     string  `long:"auth-pass" description:"HTTP basic auth password"`
+	SkipOpen                     bool    `short:"s" long:"skip-open" description:"Skip browser open on start"`
+	Sessions                     bool    `long:"sessions" description:"Enable multiple database sessions"`
+	Prefix                       string  `long:"prefix" description:"Add a url prefix"`
+	ReadOnly                     bool    `long:"readonly" description:"Run database connection in readonly mode"`
+	ReadOnlyDenyFunctions        string  `long:"readonly-deny-functions" description:"Comma-separated list of additional functions not allowed in readonly mode"`
+	LockSession                  bool    `long:"lock-session" description:"Lock session to a single database connection"`
+	BackendActions               bool    `long:"backend-actions" description:"Allow cancelling and terminating running queries from the activity view"`
+	Bookmark                     string  `short:"b" long:"bookmark" description:"Bookmark to use for connection. Bookmark files are stored under $HOME/.pgweb/bookmarks/*.toml" default:""`
+	BookmarksDir                 string  `long:"bookmarks-dir" description:"Overrides default directory for bookmark files to search" default:""`
+	BookmarksOnly                bool    `long:"bookmarks-only" description:"Allow only connections from bookmarks"`
+	ManageBookmarks              bool    `long:"manage-bookmarks" description:"Allow creating, updating and deleting bookmarks via API"`
+	BookmarksKeyFile             string  `long:"bookmarks-key-file" description:"File with base64 encoded master key used to encrypt bookmark passwords"`
+	BookmarksIdentity            string  `long:"bookmarks-identity" description:"Age X25519 identity file used to encrypt bookmark passwords"`
+	QueriesDir                   string  `long:"queries-dir" description:"Overrides default directory for local queries"`
+	SavedQueries                 bool    `long:"saved-queries" description:"Allow creating, updating and deleting local queries via API"`
+	QueriesGit                   bool    `long:"queries-git" description:"Read local queries commit history from the git repository of the queries directory"`
+	QueriesGitRef                string  `long:"queries-git-ref" description:"Read local queries from the git branch or tag instead of the working tree"`
+	QueriesGitPoll               uint    `long:"queries-git-poll" description:"Interval in seconds to check the queries git repository for changes" default:"30"`
+	Scheduler                    bool    `long:"scheduler" description:"Run local queries with a schedule against their bookmarks"`
+	SnapshotsDir                 string  `long:"snapshots-dir" description:"Overrides default directory for scheduled query results"`
+	AlertWebhook                 string  `long:"alert-webhook" description:"Webhook URL for scheduled query alerts"`
+	AlertWebhookFormat           string  `long:"alert-webhook-format" description:"Alert webhook payload format, one of 'json', 'slack'" default:"json"`
+	SnapshotsLimit               uint    `long:"snapshots-limit" description:"Maximum number of results to keep per scheduled query" default:"100"`
+	SnapshotsRowLimit            uint    `long:"snapshots-row-limit" description:"Maximum number of result rows to keep per scheduled query run, 0 for no limit" default:"1000"`
+	HistoryStore                 string  `long:"history-store" description:"Query history storage, one of 'memory', 'file'" default:"memory"`
+	HistoryFile                  string  `long:"history-file" description:"Overrides default query history file"`
+	HistoryLimit                 uint    `long:"history-limit" description:"Maximum number of query history records to keep" default:"1000"`
+	DisablePrettyJSON            bool    `long:"no-pretty-json" description:"Disable JSON formatting feature for result export"`
+	DisableSSH                   bool    `long:"no-ssh" description:"Disable database connections via SSH"`
+	DisableConfigWatch           bool    `long:"no-config-watch" description:"Disable watching bookmarks and local queries directories, files are read on every request"`
+	ConnectBackend               string  `long:"connect-backend" description:"Enable database authentication through a third party backend"`
+	ConnectToken                 string  `long:"connect-token" description:"Authentication token for the third-party connect backend"`
+	ConnectHeaders               string  `long:"connect-headers" description:"List of headers to pass to the connect backend"`
+	DisableConnectionIdleTimeout bool    `long:"no-idle-timeout" description:"Disable connection idle timeout"`
+	ConnectionIdleTimeout        int     `long:"idle-timeout" description:"Set connection idle timeout in minutes" default:"180"`
+	QueryTimeout                 uint    `long:"query-timeout" description:"Set global query execution timeout in seconds" default:"300"`
+	MaxQueryCost                 float64 `long:"max-query-cost" description:"Require confirmation for queries with planner estimated cost above the limit"`
+	MaxQueryRows                 int64   `long:"max-query-rows" description:"Require confirmation for queries with planner estimated rows above the limit"`
+	QueryRowLimit                uint    `long:"query-row-limit" description:"Maximum number of rows returned by interactive queries, full result is available via export"`
+	JobTTL                       uint    `long:"job-ttl" description:"Set expiration time of asynchronous query results in minutes, 0 to remove results once fetched" default:"15"`
+	Cors                         bool    `long:"cors" description:"Enable Cross-Origin Resource Sharing (CORS)"`
+	CorsOrigin                   string  `long:"cors-origin" description:"Allowed CORS origins" default:"*"`
+	BinaryCodec                  string  `long:"binary-codec" description:"Codec for binary data serialization, one of 'none', 'hex', 'base58', 'base64'" default:"none"`
+	MetricsEnabled               bool    `long:"metrics" description:"Enable Prometheus metrics endpoint"`
+	MetricsPath                  string  `long:"metrics-path" description:"Path prefix for Prometheus metrics endpoint" default:"/metrics"`
+	MetricsAddr                  string  `long:"metrics-addr" description:"Listen host and port for Prometheus metrics server"`
 }
 
 var Opts Options
@@ -111,7 +135,8 @@ func ParseOptions(args []string) (Options, error) {
 
 	// Handle edge case where pgweb is started with a default host `localhost` and no user.
 	// When user is not set the `lib/pq` connection will fail and cause pgweb's termination.
-	if (opts.Host == "localhost" || opts.Host == "127.0.0.1") && opts.User == "" {
+	// Service user is used when connecting with the service.
+	if (opts.Host == "localhost" || opts.Host == "127.0.0.1") && opts.User == "" && opts.Service == "" {
 		if username := getCurrentUser(); username != "" {
 			opts.User = username
 		} else {
@@ -135,6 +160,7 @@ func ParseOptions(args []string) (Options, error) {
 	if opts.Sessions || opts.ConnectBackend != "" {
 		opts.Bookmark = ""
 		opts.URL = ""
+		opts.Service = ""
 		opts.Host = ""
 		opts.User = ""
 		opts.Pass = ""
@@ -167,16 +193,35 @@ func ParseOptions(args []string) (Options, error) {
 		}
 	}
 
+	// History file is shared by all clients, records of different sessions would be mixed up
+	if opts.Sessions && opts.HistoryStore == "file" {
+		return opts, errors.New("--history-store=file not supported in sessions mode")
+	}
+
 	if opts.BookmarksOnly {
 		if opts.URL != "" {
 			return opts, errors.New("--url not supported in bookmarks-only mode")
 		}
+		if opts.Service != "" {
+			return opts, errors.New("--service not supported in bookmarks-only mode")
+		}
 		if opts.Host != "" && opts.Host != "localhost" {
 			return opts, errors.New("--host not supported in bookmarks-only mode")
 		}
 		if opts.ConnectBackend != "" {
 			return opts, errors.New("--connect-backend not supported in bookmarks-only mode")
 		}
+		if opts.ManageBookmarks {
+			return opts, errors.New("--manage-bookmarks not supported in bookmarks-only mode")
+		}
+	}
+
+	if opts.QueriesGitRef != "" {
+		opts.QueriesGit = true
+
+		if opts.SavedQueries {
+			return opts, errors.New("--saved-queries not supported with --queries-git-ref")
+		}
 	}
 
 	if opts.BookmarksDir == "" {
@@ -197,6 +242,14 @@ func ParseOptions(args []string) (Options, error) {
 		if opts.QueriesDir == "" {
 			opts.QueriesDir = filepath.Join(homePath, ".pgweb/queries")
 		}
+
+		if opts.SnapshotsDir == "" {
+			opts.SnapshotsDir = filepath.Join(homePath, ".pgweb/snapshots")
+		}
+
+		if opts.HistoryFile == "" {
+			opts.HistoryFile = filepath.Join(homePath, ".pgweb/history.jsonl")
+		}
 	}
 
 	return opts, nil
@@ -246,5 +299,6 @@ func AvailableEnvVars() string {
 		"  " + envVarPrefix + "AUTH_USER     HTTP basic auth username",
 		"  " + envVarPrefix + "AUTH_PASS     HTTP basic auth password",
 		"  " + envVarPrefix + "BOOKMARKS_DIR Overrides default directory for bookmark files",
+		"  " + envVarPrefix + "BOOKMARKS_KEY Base64 encoded master key used to encrypt bookmark passwords",
 	}, "\n")
 }
@@ -36,6 +36,9 @@ func TestParseOptions(t *testing.T) {
 		opts, err := ParseOptions([]string{"--sessions", "1"})
 		assert.NoError(t, err)
 		assert.Equal(t, true, opts.Sessions)
+
+		_, err = ParseOptions([]string{"--sessions", "--history-store", "file"})
+		assert.EqualError(t, err, "--history-store=file not supported in sessions mode")
 	})
 
 	t.Run("url prefix", func(t *testing.T) {
@@ -101,6 +104,16 @@ func TestParseOptions(t *testing.T) {
 		assert.Equal(t, flagDir, opts.BookmarksDir)
 	})
 
+	t.Run("queries git ref", func(t *testing.T) {
+		opts, err := ParseOptions([]string{"--queries-git-ref", "main"})
+		assert.NoError(t, err)
+		assert.Equal(t, true, opts.QueriesGit)
+		assert.Equal(t, uint(30), opts.QueriesGitPoll)
+
+		_, err = ParseOptions([]string{"--queries-git-ref", "main", "--saved-queries"})
+		assert.EqualError(t, err, "--saved-queries not supported with --queries-git-ref")
+	})
+
 	t.Run("bookmarks only mode", func(t *testing.T) {
 		_, err := ParseOptions([]string{"--bookmarks-only"})
 		assert.NoError(t, err)
@@ -108,10 +121,16 @@ func TestParseOptions(t *testing.T) {
 		_, err = ParseOptions([]string{"--bookmarks-only", "--url", "test"})
 		assert.EqualError(t, err, "--url not supported in bookmarks-only mode")
 
+		_, err = ParseOptions([]string{"--bookmarks-only", "--service", "test"})
+		assert.EqualError(t, err, "--service not supported in bookmarks-only mode")
+
 		_, err = ParseOptions([]string{"--bookmarks-only", "--host", "test", "--port", "5432"})
 		assert.EqualError(t, err, "--host not supported in bookmarks-only mode")
 
 		_, err = ParseOptions([]string{"--bookmarks-only", "--connect-backend", "test", "--sessions", "--connect-token", "token", "--url", "127.0.0.2"})
 		assert.EqualError(t, err, "--connect-backend not supported in bookmarks-only mode")
+
+		_, err = ParseOptions([]string{"--bookmarks-only", "--manage-bookmarks"})
+		assert.EqualError(t, err, "--manage-bookmarks not supported in bookmarks-only mode")
 	})
 }
@@ -67,6 +67,20 @@ func FormatURL(opts command.Options) (string, error) {
 	// Get query params
 	params := valsFromQuery(uri.Query())
 
+	// Fill missing connection details from the connection service file
+	serviceName := params["service"]
+	if serviceName == "" {
+		serviceName = opts.Service
+	}
+	if serviceName != "" {
+		service, err := FindService(serviceName)
+		if err != nil {
+			return "", err
+		}
+		applyServiceURL(uri, params, service)
+		delete(params, "service")
+	}
+
 	// Determine if we need to specify sslmode if it's missing
 	if params["sslmode"] == "" {
 		if opts.SSLMode == "" {
@@ -107,7 +121,7 @@ func FormatURL(opts command.Options) (string, error) {
 
 // IsBlank returns true if command options do not contain connection details
 func IsBlank(opts command.Options) bool {
-	return opts.Host == "" && opts.User == "" && opts.DbName == "" && opts.URL == ""
+	return opts.Host == "" && opts.User == "" && opts.DbName == "" && opts.URL == "" && opts.Service == ""
 }
 
 // BuildStringFromOptions returns a new connection string built from options
@@ -119,6 +133,17 @@ func BuildStringFromOptions(opts command.Options) (string, error) {
 		return FormatURL(opts)
 	}
 
+	// Fill missing connection details from the connection service file
+	if opts.Service != "" {
+		service, err := FindService(opts.Service)
+		if err != nil {
+			return "", err
+		}
+		if opts, err = applyServiceOptions(opts, service); err != nil {
+			return "", err
+		}
+	}
+
 	// Try to detect user from current OS user
 	if opts.User == "" {
 		u, err := currentUser()
@@ -0,0 +1,253 @@
+package connection
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+
+	"github.com/sosedoff/pgweb/pkg/command"
+)
+
+var (
+	errServiceNotFound = errors.New("Service not found")
+
+	// Service parameters passed to the connection string as is
+	serviceQueryParams = []string{
+		"sslmode",
+		"sslcert",
+		"sslkey",
+		"sslrootcert",
+		"connect_timeout",
+		"application_name",
+	}
+)
+
+// Service contains connection parameters of the pg_service.conf section
+type Service struct {
+	Name   string
+	Params map[string]string
+}
+
+// ServiceFiles returns paths of the connection service files in lookup order:
+// PGSERVICEFILE or ~/.pg_service.conf, then pg_service.conf in PGSYSCONFDIR
+func ServiceFiles() []string {
+	files := []string{}
+
+	if path := os.Getenv("PGSERVICEFILE"); path != "" {
+		files = append(files, path)
+	} else if home, err := homedir.Dir(); err == nil && home != "" {
+		files = append(files, filepath.Join(home, ".pg_service.conf"))
+	}
+
+	if dir := os.Getenv("PGSYSCONFDIR"); dir != "" {
+		files = append(files, filepath.Join(dir, "pg_service.conf"))
+	}
+
+	return files
+}
+
+// ReadServiceFile parses the connection service file
+func ReadServiceFile(path string) ([]Service, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var (
+		services = []Service{}
+		current  *Service
+		lineNum  int
+	)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("syntax error in service file %s, line %d", path, lineNum)
+			}
+
+			services = append(services, Service{
+				Name:   strings.TrimSpace(line[1 : len(line)-1]),
+				Params: map[string]string{},
+			})
+			current = &services[len(services)-1]
+			continue
+		}
+
+		key, val, ok := strings.Cut(line, "=")
+		if !ok || current == nil {
+			return nil, fmt.Errorf("syntax error in service file %s, line %d", path, lineNum)
+		}
+
+		key = strings.TrimSpace(key)
+		if key == "service" {
+			return nil, fmt.Errorf("nested service specifications not supported in service file %s, line %d", path, lineNum)
+		}
+		current.Params[key] = strings.TrimSpace(val)
+	}
+
+	return services, scanner.Err()
+}
+
+// Services returns services of all connection service files.
+// Services defined in the user file take precedence over the system ones.
+func Services() ([]Service, error) {
+	result := []Service{}
+	seen := map[string]bool{}
+
+	for _, path := range ServiceFiles() {
+		services, err := ReadServiceFile(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, service := range services {
+			if !seen[service.Name] {
+				seen[service.Name] = true
+				result = append(result, service)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// ServiceNames returns names of all available services
+func ServiceNames() ([]string, error) {
+	services, err := Services()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(services))
+	for i, service := range services {
+		names[i] = service.Name
+	}
+
+	return names, nil
+}
+
+// FindService returns the service with the given name
+func FindService(name string) (*Service, error) {
+	services, err := Services()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, service := range services {
+		if service.Name == name {
+			return &service, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", errServiceNotFound, name)
+}
+
+// applyServiceOptions fills connection options from the service, options that are set take precedence.
+// Host and port are filled when they have default values.
+func applyServiceOptions(opts command.Options, service *Service) (command.Options, error) {
+	params := service.Params
+
+	if val := params["host"]; val != "" && (opts.Host == "" || opts.Host == "localhost") {
+		opts.Host = val
+	}
+	if val := params["port"]; val != "" && (opts.Port == 0 || opts.Port == 5432) {
+		port, err := strconv.Atoi(val)
+		if err != nil {
+			return opts, fmt.Errorf("invalid port in service %s: %q", service.Name, val)
+		}
+		opts.Port = port
+	}
+
+	fill := func(field *string, key string) {
+		if *field == "" {
+			*field = params[key]
+		}
+	}
+
+	fill(&opts.User, "user")
+	fill(&opts.Pass, "password")
+	fill(&opts.DbName, "dbname")
+	fill(&opts.SSLMode, "sslmode")
+	fill(&opts.SSLCert, "sslcert")
+	fill(&opts.SSLKey, "sslkey")
+	fill(&opts.SSLRootCert, "sslrootcert")
+
+	if opts.Host == "" {
+		opts.Host = "localhost"
+	}
+	if opts.Port == 0 {
+		opts.Port = 5432
+	}
+
+	return opts, nil
+}
+
+// applyServiceURL fills connection URL parts and query params from the service,
+// values that are set in the URL take precedence
+func applyServiceURL(uri *neturl.URL, query map[string]string, service *Service) {
+	params := service.Params
+
+	host, port := uri.Hostname(), uri.Port()
+	if host == "" {
+		host = params["host"]
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	if port == "" {
+		port = params["port"]
+	}
+	if port != "" {
+		uri.Host = net.JoinHostPort(host, port)
+	} else {
+		uri.Host = host
+	}
+
+	user, pass := "", ""
+	if uri.User != nil {
+		user = uri.User.Username()
+		pass, _ = uri.User.Password()
+	}
+	if user == "" {
+		user = params["user"]
+	}
+	if pass == "" {
+		pass = params["password"]
+	}
+	switch {
+	case pass != "":
+		uri.User = neturl.UserPassword(user, pass)
+	case user != "":
+		uri.User = neturl.User(user)
+	}
+
+	if strings.Trim(uri.Path, "/") == "" && params["dbname"] != "" {
+		uri.Path = "/" + params["dbname"]
+	}
+
+	for _, key := range serviceQueryParams {
+		if query[key] == "" && params[key] != "" {
+			query[key] = params[key]
+		}
+	}
+}
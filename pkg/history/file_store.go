@@ -0,0 +1,166 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStore persists history records in a JSONL file, one record per line.
+// Records are kept in memory for lookups, the file is compacted once it
+// grows over twice the records limit or when records are removed.
+type FileStore struct {
+	path   string
+	limit  int
+	lines  int
+	memory *MemoryStore
+	mu     sync.Mutex
+}
+
+func NewFileStore(path string, limit int) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	store := &FileStore{
+		path:   path,
+		limit:  limit,
+		memory: NewMemoryStore(limit),
+	}
+
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+func (s *FileStore) Add(record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.memory.Add(record); err != nil {
+		return err
+	}
+
+	if s.limit > 0 && s.lines >= s.limit*2 {
+		return s.rewrite()
+	}
+
+	return s.append(record)
+}
+
+func (s *FileStore) Find(filter Filter) ([]Record, int, error) {
+	return s.memory.Find(filter)
+}
+
+func (s *FileStore) Delete(connection string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.memory.Delete(connection, id); err != nil {
+		return err
+	}
+
+	return s.rewrite()
+}
+
+func (s *FileStore) Clear(connection string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.memory.Clear(connection); err != nil {
+		return err
+	}
+
+	return s.rewrite()
+}
+
+func (s *FileStore) load() error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		s.lines++
+
+		record := Record{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] skipping invalid history record in %q: %v\n", s.path, err)
+			continue
+		}
+		s.memory.add(record)
+	}
+
+	return scanner.Err()
+}
+
+func (s *FileStore) append(record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	s.lines++
+	return nil
+}
+
+// rewrite replaces the file with the records currently kept in memory
+func (s *FileStore) rewrite() error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".history-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	s.memory.mu.RLock()
+	records := s.memory.all()
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, record := range records {
+		if err = encoder.Encode(record); err != nil {
+			break
+		}
+	}
+	s.memory.mu.RUnlock()
+
+	if err == nil {
+		err = writer.Flush()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+
+	s.lines = len(records)
+	return nil
+}
@@ -1,21 +1,122 @@
 package history
 
 import (
+	"errors"
+	"fmt"
+	neturl "net/url"
+	"strings"
 	"time"
+
+	"github.com/tuvistavie/securerandom"
+)
+
+var (
+	ErrRecordNotFound = errors.New("history record not found")
 )
 
+// Record represents a single query execution
 type Record struct {
-	Query     string `json:"query"`
-	Timestamp string `json:"timestamp"`
+	ID         string    `json:"id"`
+	Connection string    `json:"connection"`
+	Query      string    `json:"query"`
+	Timestamp  time.Time `json:"timestamp"`
+	Duration   int64     `json:"duration_ms"`
+	RowsCount  int       `json:"rows_count"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
 }
 
-func New() []Record {
-	return make([]Record, 0)
+// Filter controls which history records are returned by the store
+type Filter struct {
+	Connection string // Connection identity, required
+	Search     string // Case-insensitive query substring
+	Status     string // One of "success", "failed" or empty for any
+	Offset     int
+	Limit      int // Zero for no limit
+}
+
+// Store persists history records keyed by the connection identity
+type Store interface {
+	// Add saves a new history record
+	Add(record Record) error
+
+	// Find returns the matching records, newest first, and the total number of matches
+	Find(filter Filter) ([]Record, int, error)
+
+	// Delete removes a single record of the connection
+	Delete(connection string, id string) error
+
+	// Clear removes all records of the connection
+	Clear(connection string) error
 }
 
 func NewRecord(query string) Record {
+	id, _ := securerandom.Uuid()
+
 	return Record{
+		ID:        id,
 		Query:     query,
-		Timestamp: time.Now().String(),
+		Timestamp: time.Now(),
+	}
+}
+
+// ConnectionID returns the connection identity without credentials, ie "user@host:port/db"
+func ConnectionID(connStr string) string {
+	var user, host, port, database string
+
+	if uri, err := neturl.Parse(connStr); err == nil && uri.Scheme != "" {
+		user = uri.User.Username()
+		host = uri.Hostname()
+		port = uri.Port()
+		database = strings.TrimPrefix(uri.Path, "/")
+	} else {
+		// Key-value connection string, ie "host=localhost user=postgres dbname=foo"
+		for _, pair := range strings.Fields(connStr) {
+			key, value, _ := strings.Cut(pair, "=")
+			value = strings.Trim(value, "'")
+
+			switch key {
+			case "user":
+				user = value
+			case "host":
+				host = value
+			case "port":
+				port = value
+			case "dbname":
+				database = value
+			}
+		}
+	}
+
+	if host == "" {
+		host = "localhost"
+	}
+	if port == "" {
+		port = "5432"
 	}
+
+	return fmt.Sprintf("%s@%s:%s/%s", user, host, port, database)
+}
+
+func (f Filter) match(record Record) bool {
+	if record.Connection != f.Connection {
+		return false
+	}
+
+	switch f.Status {
+	case "success":
+		if !record.Success {
+			return false
+		}
+	case "failed":
+		if record.Success {
+			return false
+		}
+	}
+
+	if f.Search != "" && !strings.Contains(strings.ToLower(record.Query), strings.ToLower(f.Search)) {
+		return false
+	}
+
+	return true
 }
@@ -0,0 +1,100 @@
+package history
+
+import (
+	"slices"
+	"sync"
+)
+
+// MemoryStore keeps history records in memory, up to the configured limit.
+// Oldest records are removed once the limit is reached.
+type MemoryStore struct {
+	records []Record
+	limit   int
+	mu      sync.RWMutex
+}
+
+func NewMemoryStore(limit int) *MemoryStore {
+	return &MemoryStore{
+		records: []Record{},
+		limit:   limit,
+	}
+}
+
+func (s *MemoryStore) Add(record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.add(record)
+	return nil
+}
+
+func (s *MemoryStore) Find(filter Filter) ([]Record, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matches := []Record{}
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if filter.match(s.records[i]) {
+			matches = append(matches, s.records[i])
+		}
+	}
+
+	total := len(matches)
+
+	start := min(filter.Offset, total)
+	end := total
+	if filter.Limit > 0 {
+		end = min(start+filter.Limit, total)
+	}
+
+	return matches[start:end], total, nil
+}
+
+func (s *MemoryStore) Delete(connection string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, record := range s.records {
+		if record.Connection == connection && record.ID == id {
+			s.records = append(s.records[:i], s.records[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrRecordNotFound
+}
+
+func (s *MemoryStore) Clear(connection string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := []Record{}
+	for _, record := range s.records {
+		if record.Connection != connection {
+			records = append(records, record)
+		}
+	}
+	s.records = records
+
+	return nil
+}
+
+// add appends the record and returns true if old records were removed.
+// Previous records of the same query are replaced, so each query is listed once.
+func (s *MemoryStore) add(record Record) bool {
+	s.records = slices.DeleteFunc(s.records, func(r Record) bool {
+		return r.Connection == record.Connection && r.Query == record.Query
+	})
+	s.records = append(s.records, record)
+
+	if s.limit > 0 && len(s.records) > s.limit {
+		s.records = append([]Record{}, s.records[len(s.records)-s.limit:]...)
+		return true
+	}
+
+	return false
+}
+
+func (s *MemoryStore) all() []Record {
+	return s.records
+}
@@ -0,0 +1,281 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	pgquery "github.com/wasilibs/go-pgquery"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var (
+	ErrNoStatements = errors.New("query does not contain any statements")
+
+	// Functions that could change the server state or access the server filesystem
+	DefaultDeniedFunctions = []string{
+		"dblink",
+		"dblink_connect",
+		"dblink_exec",
+		"lo_export",
+		"lo_from_bytea",
+		"lo_import",
+		"lo_put",
+		"lo_unlink",
+		"pg_cancel_backend",
+		"pg_create_logical_replication_slot",
+		"pg_create_physical_replication_slot",
+		"pg_create_restore_point",
+		"pg_drop_replication_slot",
+		"pg_file_write",
+		"pg_logical_emit_message",
+		"pg_ls_dir",
+		"pg_notify",
+		"pg_promote",
+		"pg_read_binary_file",
+		"pg_read_file",
+		"pg_reload_conf",
+		"pg_replication_origin_create",
+		"pg_replication_origin_drop",
+		"pg_rotate_logfile",
+		"pg_stat_file",
+		"pg_stat_reset",
+		"pg_stat_reset_shared",
+		"pg_stat_statements_reset",
+		"pg_switch_wal",
+		"pg_terminate_backend",
+		"set_config",
+	}
+
+	// Statement types that are allowed in read-only mode
+	readOnlyTypes = map[string]bool{
+		"select":         true,
+		"explain":        true,
+		"variable_show":  true,
+		"variable_set":   true,
+		"copy":           true,
+		"declare_cursor": true,
+		"fetch":          true,
+		"close_portal":   true,
+		"transaction":    true,
+		"prepare":        true,
+		"deallocate":     true,
+	}
+
+	// Settings that control the read-only transaction mode
+	readOnlySettings = map[string]bool{
+		"default_transaction_read_only": true,
+		"transaction_read_only":         true,
+		"session_authorization":         true,
+	}
+
+	// Statement types that can not be executed inside of a transaction block
+	nonTransactionalTypes = map[string]bool{
+		"vacuum":              true,
+		"createdb":            true,
+		"dropdb":              true,
+		"alter_system":        true,
+		"create_table_space":  true,
+		"drop_table_space":    true,
+		"create_subscription": true,
+		"drop_subscription":   true,
+	}
+
+	deniedFunctions     = map[string]bool{}
+	deniedFunctionsLock sync.RWMutex
+)
+
+// Statement contains classification of a single SQL statement
+type Statement struct {
+	Query         string   `json:"query"`         // Statement text
+	Type          string   `json:"type"`          // Statement type, ie "select", "insert", "vacuum"
+	ReadOnly      bool     `json:"read_only"`     // Statement does not modify data or server state
+	Transactional bool     `json:"transactional"` // Statement could run inside of a transaction block
+	Functions     []string `json:"functions"`     // List of functions called by the statement
+}
+
+func init() {
+	SetDeniedFunctions(nil)
+}
+
+// SetDeniedFunctions configures functions that are not allowed in read-only mode,
+// in addition to the default list.
+func SetDeniedFunctions(names []string) {
+	deniedFunctionsLock.Lock()
+	defer deniedFunctionsLock.Unlock()
+
+	deniedFunctions = map[string]bool{}
+	for _, list := range [][]string{DefaultDeniedFunctions, names} {
+		for _, name := range list {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name != "" {
+				deniedFunctions[name] = true
+			}
+		}
+	}
+}
+
+// IsDeniedFunction returns true if function is not allowed in read-only mode.
+// Function name could be schema-qualified, ie "pg_catalog.pg_terminate_backend".
+func IsDeniedFunction(name string) bool {
+	chunks := strings.Split(strings.ToLower(name), ".")
+
+	deniedFunctionsLock.RLock()
+	defer deniedFunctionsLock.RUnlock()
+
+	return deniedFunctions[chunks[len(chunks)-1]]
+}
+
+// Parse parses the query and returns a list of classified statements
+func Parse(query string) ([]Statement, error) {
+	tree, err := pgquery.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Statement, 0, len(tree.Stmts))
+	for _, raw := range tree.Stmts {
+		stmt := classify(raw.Stmt)
+
+		// Statement length is not set for the last statement without a trailing semicolon
+		end := len(query)
+		if raw.StmtLen > 0 {
+			end = int(raw.StmtLocation + raw.StmtLen)
+		}
+		stmt.Query = strings.TrimSpace(query[raw.StmtLocation:end])
+
+		result = append(result, stmt)
+	}
+
+	return result, nil
+}
+
+// CheckReadOnly returns an error when query contains statements that are not allowed
+// in read-only mode. Queries that could not be parsed are not allowed either.
+func CheckReadOnly(query string) error {
+	statements, err := Parse(query)
+	if err != nil {
+		return err
+	}
+	if len(statements) == 0 {
+		return ErrNoStatements
+	}
+
+	for _, stmt := range statements {
+		for _, name := range stmt.Functions {
+			if IsDeniedFunction(name) {
+				return fmt.Errorf("function %s is not allowed", name)
+			}
+		}
+		if !stmt.ReadOnly {
+			return fmt.Errorf("%s statement is not allowed", strings.ToUpper(strings.ReplaceAll(stmt.Type, "_", " ")))
+		}
+	}
+
+	return nil
+}
+
+func classify(node *pg_query.Node) Statement {
+	stmt := Statement{
+		Type:          nodeType(node),
+		ReadOnly:      true,
+		Transactional: true,
+		Functions:     []string{},
+	}
+
+	if !readOnlyTypes[stmt.Type] {
+		stmt.ReadOnly = false
+	}
+	if nonTransactionalTypes[stmt.Type] {
+		stmt.Transactional = false
+	}
+
+	seenFunctions := map[string]bool{}
+
+	walk(node.ProtoReflect(), func(msg protoreflect.Message) {
+		switch v := msg.Interface().(type) {
+		case *pg_query.FuncCall:
+			name := functionName(v)
+			if !seenFunctions[name] {
+				seenFunctions[name] = true
+				stmt.Functions = append(stmt.Functions, name)
+			}
+		case *pg_query.InsertStmt, *pg_query.UpdateStmt, *pg_query.DeleteStmt, *pg_query.MergeStmt, *pg_query.IntoClause:
+			// Data modifying statements could be nested in CTEs, explain or prepare statements
+			stmt.ReadOnly = false
+		case *pg_query.DefElem:
+			// Transaction options could switch the transaction into read-write mode
+			if v.Defname == "transaction_read_only" {
+				stmt.ReadOnly = false
+			}
+			if v.Defname == "concurrently" {
+				stmt.Transactional = false
+			}
+		case *pg_query.VariableSetStmt:
+			if v.Kind == pg_query.VariableSetKind_VAR_RESET_ALL || readOnlySettings[strings.ToLower(v.Name)] {
+				stmt.ReadOnly = false
+			}
+		case *pg_query.CopyStmt:
+			// Only copying data to the client is allowed
+			if v.IsFrom || v.IsProgram || v.Filename != "" {
+				stmt.ReadOnly = false
+			}
+		case *pg_query.IndexStmt:
+			if v.Concurrent {
+				stmt.Transactional = false
+			}
+		case *pg_query.DropStmt:
+			if v.Concurrent {
+				stmt.Transactional = false
+			}
+		}
+	})
+
+	return stmt
+}
+
+// nodeType returns a statement type based on the node name, ie "select_stmt" -> "select"
+func nodeType(node *pg_query.Node) string {
+	msg := node.ProtoReflect()
+
+	field := msg.WhichOneof(msg.Descriptor().Oneofs().ByName("node"))
+	if field == nil {
+		return "unknown"
+	}
+
+	return strings.TrimSuffix(string(field.Name()), "_stmt")
+}
+
+func functionName(call *pg_query.FuncCall) string {
+	parts := make([]string, 0, len(call.Funcname))
+	for _, part := range call.Funcname {
+		if str := part.GetString_(); str != nil {
+			parts = append(parts, str.Sval)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// walk calls the function for the message and all nested messages
+func walk(msg protoreflect.Message, fn func(protoreflect.Message)) {
+	fn(msg)
+
+	msg.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if field.Message() == nil || field.IsMap() {
+			return true
+		}
+
+		if field.IsList() {
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				walk(list.Get(i).Message(), fn)
+			}
+			return true
+		}
+
+		walk(value.Message(), fn)
+		return true
+	})
+}
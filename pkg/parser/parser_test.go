@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("invalid query", func(t *testing.T) {
+		_, err := Parse("SELEC 1")
+		assert.EqualError(t, err, `syntax error at or near "SELEC"`)
+	})
+
+	t.Run("multiple statements", func(t *testing.T) {
+		statements, err := Parse("SELECT pg_catalog.now(), upper('foo'); DELETE FROM books; VACUUM books")
+		require.NoError(t, err)
+		require.Len(t, statements, 3)
+
+		assert.Equal(t, Statement{Query: "SELECT pg_catalog.now(), upper('foo')", Type: "select", ReadOnly: true, Transactional: true, Functions: []string{"pg_catalog.now", "upper"}}, statements[0])
+		assert.Equal(t, Statement{Query: "DELETE FROM books", Type: "delete", ReadOnly: false, Transactional: true, Functions: []string{}}, statements[1])
+		assert.Equal(t, Statement{Query: "VACUUM books", Type: "vacuum", ReadOnly: false, Transactional: false, Functions: []string{}}, statements[2])
+	})
+
+	t.Run("nested functions", func(t *testing.T) {
+		statements, err := Parse("SELECT * FROM (SELECT lower(name) FROM t WHERE id IN (SELECT pg_terminate_backend(pid) FROM pg_stat_activity)) x")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"lower", "pg_terminate_backend"}, statements[0].Functions)
+	})
+
+	t.Run("transactional statements", func(t *testing.T) {
+		examples := map[string]bool{
+			"CREATE INDEX books_idx ON books (title)":              true,
+			"CREATE INDEX CONCURRENTLY books_idx ON books (title)": false,
+			"DROP INDEX books_idx":                                 true,
+			"DROP INDEX CONCURRENTLY books_idx":                    false,
+			"REINDEX TABLE books":                                  true,
+			"REINDEX TABLE CONCURRENTLY books":                     false,
+			"CREATE DATABASE foo":                                  false,
+			"ALTER SYSTEM SET work_mem = '64MB'":                   false,
+		}
+
+		for query, expected := range examples {
+			statements, err := Parse(query)
+			require.NoError(t, err)
+			assert.Equal(t, expected, statements[0].Transactional, query)
+		}
+	})
+}
+
+func TestCheckReadOnly(t *testing.T) {
+	examples := []struct {
+		query string
+		err   string
+	}{
+		{query: "SELECT 1"},
+		{query: "SELECT 'create table foo; drop table bar' AS value"},
+		{query: "-- CREATE TABLE foobar(id integer);\nSELECT 'foo';"},
+		{query: "/* CREATE TABLE foobar(id integer); */ SELECT 'foo';"},
+		{query: `SELECT "update", "delete" FROM changes`},
+		{query: "WITH recent AS (SELECT * FROM books) SELECT * FROM recent"},
+		{query: "EXPLAIN SELECT * FROM books"},
+		{query: "SHOW search_path"},
+		{query: "SET search_path = public"},
+		{query: "BEGIN; SELECT 1; COMMIT"},
+		{query: "COPY books TO STDOUT"},
+		{query: "", err: "query does not contain any statements"},
+		{query: "SELEC 1", err: `syntax error at or near "SELEC"`},
+		{query: "CREATE TABLE foobar(id integer)", err: "CREATE statement is not allowed"},
+		{query: "INSERT INTO books (id) VALUES (1)", err: "INSERT statement is not allowed"},
+		{query: "update books set title = 'foo'", err: "UPDATE statement is not allowed"},
+		{query: "SELECT 1; DROP TABLE books", err: "DROP statement is not allowed"},
+		{query: "TRUNCATE books", err: "TRUNCATE statement is not allowed"},
+		{query: "GRANT SELECT ON books TO foo", err: "GRANT statement is not allowed"},
+		{query: "WITH deleted AS (DELETE FROM books RETURNING *) SELECT * FROM deleted", err: "SELECT statement is not allowed"},
+		{query: "SELECT * INTO books_copy FROM books", err: "SELECT statement is not allowed"},
+		{query: "EXPLAIN ANALYZE UPDATE books SET title = 'foo'", err: "EXPLAIN statement is not allowed"},
+		{query: "COPY books FROM '/tmp/books.csv'", err: "COPY statement is not allowed"},
+		{query: "COPY books TO '/tmp/books.csv'", err: "COPY statement is not allowed"},
+		{query: "COPY (SELECT 1) TO PROGRAM 'rm -rf /tmp/data'", err: "COPY statement is not allowed"},
+		{query: "SET default_transaction_read_only = off", err: "VARIABLE SET statement is not allowed"},
+		{query: "RESET ALL", err: "VARIABLE SET statement is not allowed"},
+		{query: "SET SESSION CHARACTERISTICS AS TRANSACTION READ WRITE", err: "VARIABLE SET statement is not allowed"},
+		{query: "BEGIN READ WRITE", err: "TRANSACTION statement is not allowed"},
+		{query: "SELECT pg_terminate_backend(123)", err: "function pg_terminate_backend is not allowed"},
+		{query: "SELECT PG_CATALOG.PG_CANCEL_BACKEND(123)", err: "function pg_catalog.pg_cancel_backend is not allowed"},
+		{query: "SELECT set_config('default_transaction_read_only', 'off', false)", err: "function set_config is not allowed"},
+		{query: "SELECT pg_read_file('/etc/passwd')", err: "function pg_read_file is not allowed"},
+	}
+
+	for _, ex := range examples {
+		t.Run(ex.query, func(t *testing.T) {
+			err := CheckReadOnly(ex.query)
+			if ex.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, ex.err)
+			}
+		})
+	}
+}
+
+func TestSetDeniedFunctions(t *testing.T) {
+	defer SetDeniedFunctions(nil)
+
+	assert.NoError(t, CheckReadOnly("SELECT pg_sleep(1)"))
+	assert.True(t, IsDeniedFunction("pg_terminate_backend"))
+
+	SetDeniedFunctions([]string{" PG_SLEEP ", ""})
+	assert.EqualError(t, CheckReadOnly("SELECT pg_sleep(1)"), "function pg_sleep is not allowed")
+	assert.True(t, IsDeniedFunction("pg_catalog.pg_sleep"))
+	assert.True(t, IsDeniedFunction("pg_terminate_backend"))
+}
@@ -0,0 +1,77 @@
+package queries
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	AlertOperandRows   = "rows"   // Number of rows in the result
+	AlertOperandColumn = "column" // Column value of any result row
+	AlertOperandNumber = "number"
+	AlertOperandString = "string"
+)
+
+var (
+	reAlert      = regexp.MustCompile(`^\s*(\w+)\s*(>=|<=|==|!=|=|>|<)\s*(.+?)\s*$`)
+	reIdentifier = regexp.MustCompile(`^[a-zA-Z_]\w*$`)
+)
+
+// Alert is a condition evaluated against the scheduled query result, ie
+// "rows > 0", "total >= 100", "status != 'ok'" or "used > quota".
+type Alert struct {
+	Expression string       `json:"expression"`
+	Left       AlertOperand `json:"left"`
+	Operator   string       `json:"operator"`
+	Right      AlertOperand `json:"right"`
+}
+
+// AlertOperand is a side of the alert condition
+type AlertOperand struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+func parseAlert(input string) (*Alert, error) {
+	match := reAlert.FindStringSubmatch(input)
+	if match == nil {
+		return nil, fmt.Errorf("invalid alert expression: %q", input)
+	}
+
+	alert := &Alert{
+		Expression: strings.TrimSpace(input),
+		Left:       newAlertOperand(match[1]),
+		Operator:   match[2],
+	}
+	if alert.Operator == "=" {
+		alert.Operator = "=="
+	}
+
+	right := match[3]
+	switch {
+	case len(right) >= 2 && strings.HasPrefix(right, "'") && strings.HasSuffix(right, "'"):
+		alert.Right = AlertOperand{Type: AlertOperandString, Value: right[1 : len(right)-1]}
+	case reIdentifier.MatchString(right):
+		alert.Right = newAlertOperand(right)
+	default:
+		if _, err := strconv.ParseFloat(right, 64); err != nil {
+			return nil, fmt.Errorf("invalid alert value: %q", right)
+		}
+		alert.Right = AlertOperand{Type: AlertOperandNumber, Value: right}
+	}
+
+	if alert.Left.Type == AlertOperandRows && alert.Right.Type != AlertOperandNumber {
+		return nil, fmt.Errorf("rows must be compared to a number")
+	}
+
+	return alert, nil
+}
+
+func newAlertOperand(value string) AlertOperand {
+	if value == AlertOperandRows {
+		return AlertOperand{Type: AlertOperandRows, Value: value}
+	}
+	return AlertOperand{Type: AlertOperandColumn, Value: value}
+}
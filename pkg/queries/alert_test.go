@@ -0,0 +1,48 @@
+package queries
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseAlert(t *testing.T) {
+	examples := []struct {
+		input string
+		err   string
+		alert *Alert
+	}{
+		{
+			input: "rows > 0",
+			alert: &Alert{Expression: "rows > 0", Left: AlertOperand{"rows", "rows"}, Operator: ">", Right: AlertOperand{"number", "0"}},
+		},
+		{
+			input: " total>=100.5 ",
+			alert: &Alert{Expression: "total>=100.5", Left: AlertOperand{"column", "total"}, Operator: ">=", Right: AlertOperand{"number", "100.5"}},
+		},
+		{
+			input: "status = 'failed job'",
+			alert: &Alert{Expression: "status = 'failed job'", Left: AlertOperand{"column", "status"}, Operator: "==", Right: AlertOperand{"string", "failed job"}},
+		},
+		{
+			input: "used > quota",
+			alert: &Alert{Expression: "used > quota", Left: AlertOperand{"column", "used"}, Operator: ">", Right: AlertOperand{"column", "quota"}},
+		},
+		{input: "rows", err: `invalid alert expression: "rows"`},
+		{input: "rows ~ 1", err: `invalid alert expression: "rows ~ 1"`},
+		{input: "total > 1 2", err: `invalid alert value: "1 2"`},
+		{input: "rows > 'foo'", err: "rows must be compared to a number"},
+	}
+
+	for _, ex := range examples {
+		t.Run(ex.input, func(t *testing.T) {
+			alert, err := parseAlert(ex.input)
+			if ex.err != "" {
+				assert.EqualError(t, err, ex.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, ex.alert, alert)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package queries
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	dashboardExt = ".dashboard.toml"
+
+	defaultDashboardColumns = 12
+	defaultPanelHeight      = 1
+	defaultPanelTimeout     = 30 // seconds
+)
+
+var ErrDashboardFileNotExist = errors.New("dashboard file does not exist")
+
+// Dashboard is a set of local queries rendered together, defined in the
+// <id>.dashboard.toml file of the queries directory, ie:
+//
+//	title = "Sales"
+//	refresh = 60
+//
+//	[[panels]]
+//	query = "daily_sales"
+//	width = 6
+//	timeout = 10
+type Dashboard struct {
+	ID          string  `toml:"-" json:"id"`
+	Title       string  `toml:"title" json:"title"`
+	Description string  `toml:"description" json:"description,omitempty"`
+	Columns     int     `toml:"columns" json:"columns"` // Number of layout grid columns
+	Refresh     int     `toml:"refresh" json:"refresh"` // Default panel refresh interval in seconds, 0 to disable
+	Timeout     int     `toml:"timeout" json:"timeout"` // Default panel timeout in seconds, capped by --query-timeout
+	Panels      []Panel `toml:"panels" json:"panels"`
+}
+
+// Panel is a local query rendered on the dashboard
+type Panel struct {
+	Query   string `toml:"query" json:"query"` // Local query ID
+	Title   string `toml:"title" json:"title,omitempty"`
+	Width   int    `toml:"width" json:"width"`   // Width in grid columns
+	Height  int    `toml:"height" json:"height"` // Height in grid rows
+	Refresh int    `toml:"refresh" json:"refresh"`
+	Timeout int    `toml:"timeout" json:"timeout"`
+}
+
+// TimeoutDuration returns the panel query timeout
+func (p Panel) TimeoutDuration() time.Duration {
+	return time.Duration(p.Timeout) * time.Second
+}
+
+// validate checks the dashboard definition and applies layout defaults
+func (d *Dashboard) validate() error {
+	if len(d.Panels) == 0 {
+		return errors.New("dashboard must have at least one panel")
+	}
+	if d.Columns < 0 || d.Refresh < 0 || d.Timeout < 0 {
+		return errors.New("dashboard columns, refresh and timeout must not be negative")
+	}
+
+	if d.Title == "" {
+		d.Title = d.ID
+	}
+	if d.Columns == 0 {
+		d.Columns = defaultDashboardColumns
+	}
+	if d.Timeout == 0 {
+		d.Timeout = defaultPanelTimeout
+	}
+
+	for i := range d.Panels {
+		panel := &d.Panels[i]
+
+		if !reQueryID.MatchString(panel.Query) {
+			return fmt.Errorf("panel %d: invalid query id: %q", i+1, panel.Query)
+		}
+		if panel.Width < 0 || panel.Width > d.Columns {
+			return fmt.Errorf("panel %d: width must be between 1 and %d", i+1, d.Columns)
+		}
+		if panel.Height < 0 || panel.Refresh < 0 || panel.Timeout < 0 {
+			return fmt.Errorf("panel %d: height, refresh and timeout must not be negative", i+1)
+		}
+
+		if panel.Width == 0 {
+			panel.Width = d.Columns
+		}
+		if panel.Height == 0 {
+			panel.Height = defaultPanelHeight
+		}
+		if panel.Refresh == 0 {
+			panel.Refresh = d.Refresh
+		}
+		if panel.Timeout == 0 {
+			panel.Timeout = d.Timeout
+		}
+	}
+
+	return nil
+}
+
+// ReadDashboard reads the dashboard definition file
+func (s Store) ReadDashboard(id string) (*Dashboard, error) {
+	if !reQueryID.MatchString(id) {
+		return nil, ErrDashboardFileNotExist
+	}
+	if files := s.indexedFiles(); files != nil {
+		return files.dashboard(id)
+	}
+	return s.readDashboard(id + dashboardExt)
+}
+
+// ReadAllDashboards reads all dashboard definition files, invalid files are skipped
+func (s Store) ReadAllDashboards() ([]Dashboard, error) {
+	files := s.allFiles()
+	if files.err != nil {
+		return nil, files.err
+	}
+	return slices.Clone(files.dashboards), nil
+}
+
+func (s Store) readDashboard(name string) (*Dashboard, error) {
+	data, err := s.readFile(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrDashboardFileNotExist
+		}
+		return nil, err
+	}
+
+	dashboard := &Dashboard{
+		ID: strings.TrimSuffix(name, dashboardExt),
+	}
+	if _, err := toml.Decode(string(data), dashboard); err != nil {
+		return nil, err
+	}
+
+	if err := dashboard.validate(); err != nil {
+		return nil, err
+	}
+
+	return dashboard, nil
+}
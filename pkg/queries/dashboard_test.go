@@ -0,0 +1,94 @@
+package queries
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDashboard(t *testing.T, dir string, id string, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, id+dashboardExt), []byte(content), 0644))
+}
+
+func TestStoreReadDashboard(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+
+	writeDashboard(t, dir, "sales", `
+title = "Sales"
+refresh = 60
+
+[[panels]]
+query = "daily_sales"
+width = 6
+timeout = 5
+
+[[panels]]
+query = "top_books"
+title = "Top books"
+refresh = 10
+`)
+	writeDashboard(t, dir, "empty", `title = "Empty"`)
+	writeDashboard(t, dir, "wide", `
+[[panels]]
+query = "daily_sales"
+width = 20
+`)
+	writeDashboard(t, dir, "invalid_query", `
+[[panels]]
+query = "../foo"
+`)
+
+	t.Run("valid", func(t *testing.T) {
+		dashboard, err := store.ReadDashboard("sales")
+		require.NoError(t, err)
+
+		assert.Equal(t, "sales", dashboard.ID)
+		assert.Equal(t, "Sales", dashboard.Title)
+		assert.Equal(t, 12, dashboard.Columns)
+		assert.Equal(t, 30, dashboard.Timeout)
+		assert.Equal(t, []Panel{
+			{Query: "daily_sales", Width: 6, Height: 1, Refresh: 60, Timeout: 5},
+			{Query: "top_books", Title: "Top books", Width: 12, Height: 1, Refresh: 10, Timeout: 30},
+		}, dashboard.Panels)
+	})
+
+	examples := []struct {
+		id  string
+		err string
+	}{
+		{id: "missing", err: "dashboard file does not exist"},
+		{id: "../sales", err: "dashboard file does not exist"},
+		{id: "empty", err: "dashboard must have at least one panel"},
+		{id: "wide", err: "panel 1: width must be between 1 and 12"},
+		{id: "invalid_query", err: `panel 1: invalid query id: "../foo"`},
+	}
+
+	for _, ex := range examples {
+		t.Run(ex.id, func(t *testing.T) {
+			_, err := store.ReadDashboard(ex.id)
+			assert.EqualError(t, err, ex.err)
+		})
+	}
+}
+
+func TestStoreReadAllDashboards(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+
+	writeDashboard(t, dir, "sales", "[[panels]]\nquery = \"daily_sales\"\n")
+	writeDashboard(t, dir, "empty", `title = "Empty"`)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bookmark.toml"), []byte(`host = "localhost"`), 0644))
+
+	dashboards, err := store.ReadAllDashboards()
+	require.NoError(t, err)
+	require.Len(t, dashboards, 1)
+	assert.Equal(t, "sales", dashboards[0].ID)
+
+	_, err = NewStore(filepath.Join(dir, "missing")).ReadAllDashboards()
+	assert.Equal(t, ErrQueryDirNotExist, err)
+}
@@ -0,0 +1,375 @@
+package queries
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	gitFieldSep  = "\x1f"
+	gitRecordSep = "\x1e"
+	gitLogFormat = "--format=" + gitRecordSep + "%H" + gitFieldSep + "%an" + gitFieldSep + "%ae" + gitFieldSep + "%aI" + gitFieldSep + "%s"
+)
+
+var (
+	ErrNotGitStore   = errors.New("queries directory is not backed by git")
+	ErrStoreReadOnly = errors.New("queries are read from the git revision and could not be modified")
+	ErrInvalidRef    = errors.New("invalid git revision")
+
+	reGitRevision = regexp.MustCompile(`^[0-9a-fA-F]{4,64}$`)
+)
+
+// Commit contains details of the git commit that changed the query file
+type Commit struct {
+	Hash    string    `json:"hash"`
+	Author  string    `json:"author"`
+	Email   string    `json:"email"`
+	Date    time.Time `json:"date"`
+	Message string    `json:"message"`
+
+	path string // File path relative to the repository root, file could be renamed since
+}
+
+// gitRepo reads the queries directory from the git repository
+type gitRepo struct {
+	dir    string // Queries directory within the repository
+	prefix string // Queries directory path relative to the repository root
+	ref    string // Branch or tag to read files from, working tree is used when empty
+
+	// Latest commits of the files are cached until the repository state changes
+	commits      map[string]Commit
+	commitsState string
+	mu           sync.Mutex
+}
+
+func newGitRepo(dir string, ref string) (*gitRepo, error) {
+	repo := &gitRepo{dir: dir, ref: ref}
+
+	prefix, err := repo.run("rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotGitStore, err)
+	}
+	repo.prefix = strings.TrimSpace(prefix)
+
+	if ref != "" {
+		if strings.HasPrefix(ref, "-") {
+			return nil, ErrInvalidRef
+		}
+		if _, err := repo.run("rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRef, ref)
+		}
+	}
+
+	return repo, nil
+}
+
+func (g *gitRepo) run(args ...string) (string, error) {
+	return g.runWithInput("", args...)
+}
+
+func (g *gitRepo) runWithInput(input string, args ...string) (string, error) {
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+
+	cmd := exec.Command("git", append([]string{"-C", g.dir}, args...)...)
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+
+	return stdout.String(), nil
+}
+
+// revision returns the revision used to read files and history
+func (g *gitRepo) revision() string {
+	if g.ref != "" {
+		return g.ref
+	}
+	return "HEAD"
+}
+
+// readFile reads the file of the queries directory at the given revision
+func (g *gitRepo) readFile(revision string, name string) ([]byte, error) {
+	return g.readPath(revision, g.prefix+name)
+}
+
+// readPath reads the file at the given revision, path is relative to the repository root
+func (g *gitRepo) readPath(revision string, path string) ([]byte, error) {
+	// Batch mode reports missing objects in the output, so a single process is enough
+	out, err := g.runWithInput(revision+":"+path+"\n", "cat-file", "--batch")
+	if err != nil {
+		return nil, err
+	}
+
+	header, data, _ := strings.Cut(out, "\n")
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return nil, os.ErrNotExist
+	}
+	if fields[1] != "blob" {
+		return nil, fmt.Errorf("%s is not a file", path)
+	}
+
+	size, err := strconv.Atoi(fields[2])
+	if err != nil || size > len(data) {
+		return nil, fmt.Errorf("unable to read %s: unexpected git output", path)
+	}
+
+	return []byte(data[:size]), nil
+}
+
+// listFiles returns names of the files in the queries directory at the configured ref
+func (g *gitRepo) listFiles() ([]string, error) {
+	out, err := g.run("ls-tree", "-z", "--full-tree", "--name-only", g.ref+":"+g.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, name := range strings.Split(out, "\x00") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// lastCommits returns the latest commit of every file in the queries directory
+func (g *gitRepo) lastCommits() (map[string]Commit, error) {
+	state, err := g.state()
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.commits != nil && g.commitsState == state {
+		return g.commits, nil
+	}
+
+	commits, err := g.readLastCommits()
+	if err != nil {
+		return nil, err
+	}
+	g.commits = commits
+	g.commitsState = state
+
+	return commits, nil
+}
+
+func (g *gitRepo) readLastCommits() (map[string]Commit, error) {
+	out, err := g.run("log", "--relative", "--name-only", gitLogFormat, g.revision(), "--", ".")
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]Commit{}
+
+	for _, record := range strings.Split(out, gitRecordSep) {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		if len(lines) == 0 {
+			continue
+		}
+
+		commit, ok := parseCommit(lines[0])
+		if !ok {
+			continue
+		}
+
+		// Log is sorted by date, the first seen commit is the latest one
+		for _, name := range lines[1:] {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			if _, seen := result[name]; !seen {
+				result[name] = commit
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// history returns commits that changed the file, newest first. Renames are followed,
+// so every commit contains the file path at that revision.
+func (g *gitRepo) history(name string, limit int) ([]Commit, error) {
+	args := []string{"log", "--follow", "--name-only", gitLogFormat}
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("--max-count=%d", limit))
+	}
+
+	out, err := g.run(append(args, g.revision(), "--", name)...)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := []Commit{}
+	for _, record := range strings.Split(out, gitRecordSep) {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+
+		commit, ok := parseCommit(lines[0])
+		if !ok {
+			continue
+		}
+		for _, path := range lines[1:] {
+			if path = strings.TrimSpace(path); path != "" {
+				commit.path = path
+			}
+		}
+		commits = append(commits, commit)
+	}
+
+	return commits, nil
+}
+
+// state returns a fingerprint of the repository that changes along with the queries
+func (g *gitRepo) state() (string, error) {
+	head, err := g.run("rev-parse", g.revision())
+	if err != nil {
+		return "", err
+	}
+	if g.ref != "" {
+		return head, nil
+	}
+
+	// Working tree changes are not committed yet
+	status, err := g.run("status", "--porcelain", "--untracked-files=all", "--", ".")
+	if err != nil {
+		return "", err
+	}
+
+	return head + status, nil
+}
+
+func parseCommit(line string) (Commit, bool) {
+	fields := strings.SplitN(line, gitFieldSep, 5)
+	if len(fields) != 5 {
+		return Commit{}, false
+	}
+
+	date, err := time.Parse(time.RFC3339, fields[3])
+	if err != nil {
+		return Commit{}, false
+	}
+
+	return Commit{
+		Hash:    fields[0],
+		Author:  fields[1],
+		Email:   fields[2],
+		Date:    date,
+		Message: fields[4],
+	}, true
+}
+
+// Commits returns the latest commit of every query, keyed by query id.
+// Queries that are not committed yet are not included.
+func (s Store) Commits() (map[string]Commit, error) {
+	if s.git == nil {
+		return nil, ErrNotGitStore
+	}
+
+	commits, err := s.git.lastCommits()
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]Commit{}
+	for name, commit := range commits {
+		if filepath.Ext(name) == ".sql" && !strings.Contains(name, "/") {
+			result[strings.TrimSuffix(name, ".sql")] = commit
+		}
+	}
+
+	return result, nil
+}
+
+// History returns revisions of the query file, newest first
+func (s Store) History(id string, limit int) ([]Commit, error) {
+	if s.git == nil {
+		return nil, ErrNotGitStore
+	}
+	if !reQueryID.MatchString(id) {
+		return nil, ErrQueryFileNotExist
+	}
+
+	return s.git.history(id+".sql", limit)
+}
+
+// ReadRevision reads the query file at the given commit
+func (s Store) ReadRevision(id string, revision string) (*Query, error) {
+	if s.git == nil {
+		return nil, ErrNotGitStore
+	}
+	if !reQueryID.MatchString(id) {
+		return nil, ErrQueryFileNotExist
+	}
+	if !reGitRevision.MatchString(revision) {
+		return nil, ErrInvalidRef
+	}
+
+	// Query file could have a different name at the given revision
+	commits, err := s.git.history(id+".sql", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := slices.IndexFunc(commits, func(commit Commit) bool {
+		return strings.HasPrefix(commit.Hash, strings.ToLower(revision))
+	})
+	if idx < 0 || commits[idx].path == "" {
+		return nil, ErrQueryFileNotExist
+	}
+
+	data, err := s.git.readPath(commits[idx].Hash, commits[idx].path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrQueryFileNotExist
+		}
+		return nil, err
+	}
+
+	return parseQuery(filepath.Join(s.dir, id+".sql"), data)
+}
+
+// Watch polls the git repository and calls the callback once the revision
+// or the working tree of the queries directory changes
+func (s Store) Watch(interval time.Duration, onChange func()) {
+	if s.git == nil {
+		return
+	}
+
+	last, err := s.git.state()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] unable to read queries git state: %v\n", err)
+	}
+
+	for range time.Tick(interval) {
+		state, err := s.git.state()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] unable to read queries git state: %v\n", err)
+			continue
+		}
+		if state != last {
+			last = state
+			onChange()
+		}
+	}
+}
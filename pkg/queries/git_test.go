@@ -0,0 +1,174 @@
+package queries
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=John Doe",
+		"GIT_AUTHOR_EMAIL=john@example.com",
+		"GIT_COMMITTER_NAME=John Doe",
+		"GIT_COMMITTER_EMAIL=john@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null",
+	)
+
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	return strings.TrimSpace(string(out))
+}
+
+func commitQuery(t *testing.T, repo string, name string, content string, message string) string {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "queries", name), []byte(content), 0644))
+	runGit(t, repo, "add", "-A")
+	runGit(t, repo, "commit", "-q", "-m", message)
+
+	return runGit(t, repo, "rev-parse", "HEAD")
+}
+
+func TestGitStore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+	dir := filepath.Join(repo, "queries")
+	require.NoError(t, os.Mkdir(dir, 0755))
+
+	runGit(t, repo, "init", "-q", "-b", "main")
+	first := commitQuery(t, repo, "foo.sql", "-- pgweb: host=\"localhost\"\nselect 1", "Add foo")
+	commitQuery(t, repo, "bar.sql", "-- pgweb: host=\"localhost\"\nselect id, name, created_at from bar order by created_at desc", "Add bar")
+	runGit(t, repo, "tag", "v1")
+	second := commitQuery(t, repo, "foo.sql", "-- pgweb: host=\"localhost\"\nselect 2", "Update foo")
+
+	// Uncommitted changes are only visible in the working tree
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "baz.sql"), []byte("-- pgweb: host=\"localhost\"\nselect 3"), 0644))
+
+	t.Run("not a repository", func(t *testing.T) {
+		_, err := NewGitStore(t.TempDir(), "")
+		assert.ErrorIs(t, err, ErrNotGitStore)
+	})
+
+	t.Run("invalid ref", func(t *testing.T) {
+		_, err := NewGitStore(dir, "v2")
+		assert.ErrorIs(t, err, ErrInvalidRef)
+
+		_, err = NewGitStore(dir, "--output=foo")
+		assert.ErrorIs(t, err, ErrInvalidRef)
+	})
+
+	t.Run("working tree", func(t *testing.T) {
+		store, err := NewGitStore(dir, "")
+		require.NoError(t, err)
+		assert.True(t, store.IsGit())
+		assert.False(t, store.IsReadOnly())
+
+		queries, err := store.ReadAll()
+		require.NoError(t, err)
+		assert.Len(t, queries, 3)
+
+		commits, err := store.Commits()
+		require.NoError(t, err)
+		assert.Len(t, commits, 2)
+		assert.Equal(t, second, commits["foo"].Hash)
+		assert.Equal(t, "Update foo", commits["foo"].Message)
+		assert.Equal(t, "John Doe", commits["foo"].Author)
+		assert.Equal(t, "john@example.com", commits["foo"].Email)
+		assert.Equal(t, "Add bar", commits["bar"].Message)
+	})
+
+	t.Run("tag", func(t *testing.T) {
+		store, err := NewGitStore(dir, "v1")
+		require.NoError(t, err)
+		assert.True(t, store.IsReadOnly())
+
+		queries, err := store.ReadAll()
+		require.NoError(t, err)
+		assert.Len(t, queries, 2)
+
+		query, err := store.Read("foo")
+		require.NoError(t, err)
+		assert.Equal(t, "select 1", query.Data)
+		assert.Equal(t, filepath.Join(dir, "foo.sql"), query.Path)
+
+		_, err = store.Read("baz")
+		assert.Equal(t, ErrQueryFileNotExist, err)
+
+		commits, err := store.Commits()
+		require.NoError(t, err)
+		assert.Equal(t, first, commits["foo"].Hash)
+
+		_, err = store.Create(SavedQuery{ID: "new", Host: "localhost", Query: "select 1"})
+		assert.Equal(t, ErrStoreReadOnly, err)
+		assert.Equal(t, ErrStoreReadOnly, store.Delete("foo"))
+	})
+
+	t.Run("history", func(t *testing.T) {
+		store, err := NewGitStore(dir, "main")
+		require.NoError(t, err)
+
+		commits, err := store.History("foo", 0)
+		require.NoError(t, err)
+		require.Len(t, commits, 2)
+		assert.Equal(t, second, commits[0].Hash)
+		assert.Equal(t, first, commits[1].Hash)
+
+		commits, err = store.History("foo", 1)
+		require.NoError(t, err)
+		assert.Len(t, commits, 1)
+
+		query, err := store.ReadRevision("foo", first)
+		require.NoError(t, err)
+		assert.Equal(t, "select 1", query.Data)
+
+		_, err = store.ReadRevision("foo", "HEAD~1")
+		assert.Equal(t, ErrInvalidRef, err)
+
+		_, err = store.ReadRevision("bar", first)
+		assert.Equal(t, ErrQueryFileNotExist, err)
+	})
+
+	t.Run("renamed", func(t *testing.T) {
+		store, err := NewGitStore(dir, "")
+		require.NoError(t, err)
+
+		commits, err := store.Commits()
+		require.NoError(t, err)
+		assert.Contains(t, commits, "bar")
+
+		runGit(t, repo, "mv", "queries/bar.sql", "queries/qux.sql")
+		runGit(t, repo, "commit", "-q", "-m", "Rename bar")
+
+		// Cached commits are refreshed once the repository changes
+		commits, err = store.Commits()
+		require.NoError(t, err)
+		assert.Equal(t, "Rename bar", commits["qux"].Message)
+
+		history, err := store.History("qux", 0)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, "Add bar", history[1].Message)
+
+		query, err := store.ReadRevision("qux", history[1].Hash[:7])
+		require.NoError(t, err)
+		assert.Contains(t, query.Data, "from bar")
+	})
+
+	t.Run("not git", func(t *testing.T) {
+		_, err := NewStore(dir).History("foo", 0)
+		assert.Equal(t, ErrNotGitStore, err)
+	})
+}
@@ -0,0 +1,148 @@
+package queries
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// index is an in-memory copy of the queries directory
+type index struct {
+	files *files
+	mu    sync.RWMutex
+}
+
+// files contains parsed query and dashboard files of the queries directory
+type files struct {
+	queries    []Query
+	dashboards []Dashboard
+	ids        map[string]bool   // All query ids, including files without metadata
+	errors     map[string]string // Errors of invalid files, keyed by file name
+	err        error
+}
+
+// EnableIndex keeps queries and dashboards in memory, the index must be reloaded once files change
+func (s *Store) EnableIndex() {
+	s.index = &index{}
+	s.Reload()
+}
+
+// Reload re-reads the queries directory into the index
+func (s Store) Reload() {
+	if s.index == nil {
+		return
+	}
+
+	files := s.scan()
+
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+	s.index.files = files
+}
+
+// Errors returns errors of invalid query and dashboard files, keyed by file name
+func (s Store) Errors() (map[string]string, error) {
+	files := s.indexedFiles()
+	if files == nil {
+		files = s.scan()
+	}
+	return files.errors, files.err
+}
+
+// indexedFiles returns the indexed files, nil when index is disabled
+func (s Store) indexedFiles() *files {
+	if s.index == nil {
+		return nil
+	}
+
+	s.index.mu.RLock()
+	defer s.index.mu.RUnlock()
+	return s.index.files
+}
+
+// allFiles returns the indexed files or reads the directory
+func (s Store) allFiles() *files {
+	if files := s.indexedFiles(); files != nil {
+		return files
+	}
+
+	files := s.scan()
+	for name, msg := range files.errors {
+		fmt.Fprintf(os.Stderr, "[WARN] skipping %q file due to error: %v\n", name, msg)
+	}
+	return files
+}
+
+// scan reads all query and dashboard files, errors are collected by file name
+func (s Store) scan() *files {
+	result := &files{
+		queries:    []Query{},
+		dashboards: []Dashboard{},
+		ids:        map[string]bool{},
+		errors:     map[string]string{},
+	}
+
+	names, err := s.listFiles()
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	for _, name := range names {
+		switch {
+		case strings.HasSuffix(name, dashboardExt):
+			dashboard, err := s.readDashboard(name)
+			if err != nil {
+				result.errors[name] = err.Error()
+				continue
+			}
+			result.dashboards = append(result.dashboards, *dashboard)
+		case filepath.Ext(name) == ".sql":
+			query, err := s.readQuery(name)
+			if err != nil {
+				result.errors[name] = err.Error()
+				continue
+			}
+			result.ids[strings.TrimSuffix(name, ".sql")] = true
+			if query != nil {
+				result.queries = append(result.queries, *query)
+			}
+		}
+	}
+
+	return result
+}
+
+func (f *files) query(id string) (*Query, error) {
+	if errMsg, ok := f.errors[id+".sql"]; ok {
+		return nil, fmt.Errorf("%s", errMsg)
+	}
+	if !f.ids[id] {
+		return nil, ErrQueryFileNotExist
+	}
+
+	for _, query := range f.queries {
+		if query.ID == id {
+			return &query, nil
+		}
+	}
+
+	// Query file does not have metadata
+	return nil, nil
+}
+
+func (f *files) dashboard(id string) (*Dashboard, error) {
+	if errMsg, ok := f.errors[id+dashboardExt]; ok {
+		return nil, fmt.Errorf("%s", errMsg)
+	}
+
+	for _, dashboard := range f.dashboards {
+		if dashboard.ID == id {
+			return &dashboard, nil
+		}
+	}
+
+	return nil, ErrDashboardFileNotExist
+}
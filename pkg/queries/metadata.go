@@ -6,6 +6,10 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/sosedoff/pgweb/pkg/chart"
 )
 
 var (
@@ -14,18 +18,25 @@ var (
 	reMatchAll    = regexp.MustCompile(`^(.+)$`)
 	reExpression  = regexp.MustCompile(`[\[\]\(\)\+\*]+`)
 
-	allowedKeys  = []string{"title", "description", "host", "user", "database", "mode", "timeout"}
+	allowedKeys  = []string{"title", "description", "tags", "folder", "host", "user", "database", "mode", "timeout", "schedule", "bookmark", "alert", "chart", "chart_x", "chart_y", "chart_series", "chart_aggregate"}
 	allowedModes = map[string]bool{"readonly": true, "*": true}
 )
 
 type Metadata struct {
 	Title       string
 	Description string
+	Tags        []string
+	Folder      string
 	Host        field
 	User        field
 	Database    field
 	Mode        field
 	Timeout     *time.Duration
+	Params      []Param
+	Schedule    string
+	Bookmark    string
+	Alert       *Alert
+	Chart       *chart.Definition
 }
 
 func parseMetadata(input string) (*Metadata, error) {
@@ -86,14 +97,65 @@ func parseMetadata(input string) (*Metadata, error) {
 		timeout = &timeoutVal
 	}
 
+	params, err := parseParams(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scheduled queries run against a bookmarked connection
+	if fields["schedule"] != "" || fields["bookmark"] != "" {
+		if fields["schedule"] == "" || fields["bookmark"] == "" {
+			return nil, fmt.Errorf("schedule and bookmark fields must be set together")
+		}
+		if _, err := cron.ParseStandard(fields["schedule"]); err != nil {
+			return nil, fmt.Errorf(`error initializing "schedule" field: %w`, err)
+		}
+	}
+
+	// Alerts are evaluated after scheduled runs only
+	var alert *Alert
+	if fields["alert"] != "" {
+		if fields["schedule"] == "" {
+			return nil, fmt.Errorf("alert field requires schedule")
+		}
+		alert, err = parseAlert(fields["alert"])
+		if err != nil {
+			return nil, fmt.Errorf(`error initializing "alert" field: %w`, err)
+		}
+	}
+
+	// Chart columns are validated against the result once the query is executed
+	var chartDef *chart.Definition
+	if fields["chart"] != "" {
+		chartDef, err = chart.NewDefinition(
+			fields["chart"],
+			fields["chart_x"],
+			fields["chart_y"],
+			fields["chart_series"],
+			fields["chart_aggregate"],
+		)
+		if err != nil {
+			return nil, fmt.Errorf(`error initializing "chart" field: %w`, err)
+		}
+	} else if fields["chart_x"] != "" || fields["chart_y"] != "" || fields["chart_series"] != "" || fields["chart_aggregate"] != "" {
+		return nil, fmt.Errorf("chart field must be set")
+	}
+
 	return &Metadata{
 		Title:       fields["title"],
 		Description: fields["description"],
+		Tags:        parseTags(fields["tags"]),
+		Folder:      fields["folder"],
 		Host:        hostField,
 		User:        userField,
 		Database:    dbField,
 		Mode:        modeField,
 		Timeout:     timeout,
+		Params:      params,
+		Schedule:    fields["schedule"],
+		Bookmark:    fields["bookmark"],
+		Alert:       alert,
+		Chart:       chartDef,
 	}, nil
 }
 
@@ -113,7 +175,7 @@ func parseFields(input string) (map[string]string, error) {
 
 	for _, match := range matches {
 		content := reMetaContent.FindAllStringSubmatch(match[1], -1)
-		if len(content) == 0 {
+		if len(content) == 0 || isParamLine(content) {
 			continue
 		}
 
@@ -136,6 +198,17 @@ func parseFields(input string) (map[string]string, error) {
 	return result, nil
 }
 
+// parseTags returns a list of tags from a comma-separated string
+func parseTags(input string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(input, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
 func sanitizeMetadata(input string) string {
 	lines := []string{}
 	for _, line := range strings.Split(input, "\n") {
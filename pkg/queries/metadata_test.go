@@ -95,6 +95,50 @@ func Test_parseMetadata(t *testing.T) {
 			input: `--pgweb: host="localhost" timeout="foo"`,
 			err:   `error initializing "timeout" field: strconv.Atoi: parsing "foo": invalid syntax`,
 		},
+		{
+			input: "-- pgweb: host=\"localhost\" folder=\"reports\"\n-- pgweb: tags=\"daily, sales,,\"",
+			check: func(m *Metadata) bool {
+				return m.Folder == "reports" &&
+					len(m.Tags) == 2 && m.Tags[0] == "daily" && m.Tags[1] == "sales"
+			},
+		},
+		{
+			input: `-- pgweb: host="localhost" schedule="*/5 * * * *" bookmark="reports"`,
+			check: func(m *Metadata) bool {
+				return m.Schedule == "*/5 * * * *" && m.Bookmark == "reports"
+			},
+		},
+		{
+			input: `-- pgweb: host="localhost" schedule="@daily"`,
+			err:   `schedule and bookmark fields must be set together`,
+		},
+		{
+			input: `-- pgweb: host="localhost" schedule="foo" bookmark="reports"`,
+			err:   `error initializing "schedule" field`,
+		},
+		{
+			input: `-- pgweb: host="localhost" alert="rows > 0"`,
+			err:   `alert field requires schedule`,
+		},
+		{
+			input: `-- pgweb: host="localhost" schedule="@daily" bookmark="reports" alert="rows"`,
+			err:   `error initializing "alert" field: invalid alert expression: "rows"`,
+		},
+		{
+			input: `-- pgweb: host="localhost" chart="line" chart_x="day" chart_y="total, count"`,
+			check: func(m *Metadata) bool {
+				return m.Chart != nil && m.Chart.Type == "line" && m.Chart.X == "day" &&
+					len(m.Chart.Y) == 2 && m.Chart.Y[1] == "count" && m.Chart.Aggregate == "none"
+			},
+		},
+		{
+			input: `-- pgweb: host="localhost" chart="area" chart_x="day" chart_y="total"`,
+			err:   `error initializing "chart" field: invalid chart type: "area"`,
+		},
+		{
+			input: `-- pgweb: host="localhost" chart_x="day"`,
+			err:   `chart field must be set`,
+		},
 		{
 			input: `-- pgweb: host="local(host|dev)"`,
 			check: func(m *Metadata) bool {
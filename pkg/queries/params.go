@@ -0,0 +1,224 @@
+package queries
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ParamString    = "string"
+	ParamInteger   = "integer"
+	ParamNumber    = "number"
+	ParamBoolean   = "boolean"
+	ParamDate      = "date"
+	ParamTimestamp = "timestamp"
+)
+
+var (
+	reParamName = regexp.MustCompile(`^[a-zA-Z_]\w*$`)
+
+	allowedParamKeys  = []string{"param", "type", "default", "required", "values", "description"}
+	allowedParamTypes = []string{ParamString, ParamInteger, ParamNumber, ParamBoolean, ParamDate, ParamTimestamp}
+
+	timestampLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04"}
+)
+
+// Param describes a query parameter bound as a positional argument.
+// Parameters are declared one per line and bound in the order of declaration,
+// ie the first parameter is available as $1 in the query.
+type Param struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Default     string   `json:"default,omitempty"`
+	Required    bool     `json:"required"`
+	Values      []string `json:"values,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// BindParams validates the input values and returns the query arguments.
+// Missing or blank values are replaced with parameter defaults.
+func (q Query) BindParams(values map[string]string) ([]interface{}, error) {
+	if q.Meta == nil {
+		return nil, nil
+	}
+
+	args := make([]interface{}, 0, len(q.Meta.Params))
+	for _, param := range q.Meta.Params {
+		arg, err := param.bind(values[param.Name])
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	return args, nil
+}
+
+func (p Param) bind(input string) (interface{}, error) {
+	if strings.TrimSpace(input) == "" {
+		input = p.Default
+	}
+	if input == "" {
+		if p.Required {
+			return nil, fmt.Errorf("parameter %q is required", p.Name)
+		}
+		return nil, nil
+	}
+
+	if len(p.Values) > 0 && !slices.Contains(p.Values, input) {
+		return nil, fmt.Errorf("parameter %q must be one of: %s", p.Name, strings.Join(p.Values, ", "))
+	}
+
+	value, err := p.convert(input)
+	if err != nil {
+		return nil, fmt.Errorf("parameter %q must be a valid %s", p.Name, p.Type)
+	}
+
+	return value, nil
+}
+
+// convert returns the typed parameter value
+func (p Param) convert(input string) (interface{}, error) {
+	input = strings.TrimSpace(input)
+
+	switch p.Type {
+	case ParamInteger:
+		return strconv.ParseInt(input, 10, 64)
+	case ParamNumber:
+		return strconv.ParseFloat(input, 64)
+	case ParamBoolean:
+		return strconv.ParseBool(input)
+	case ParamDate:
+		if _, err := time.Parse("2006-01-02", input); err != nil {
+			return nil, err
+		}
+		return input, nil
+	case ParamTimestamp:
+		for _, layout := range timestampLayouts {
+			if val, err := time.Parse(layout, input); err == nil {
+				return val, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid timestamp: %q", input)
+	default:
+		return input, nil
+	}
+}
+
+// render returns the metadata line of the parameter
+func (p Param) render() string {
+	fields := map[string]string{
+		"param":       p.Name,
+		"type":        p.Type,
+		"default":     p.Default,
+		"values":      strings.Join(p.Values, ","),
+		"description": p.Description,
+	}
+	if p.Required {
+		fields["required"] = "true"
+	}
+
+	chunks := []string{}
+	for _, key := range allowedParamKeys {
+		if fields[key] != "" {
+			chunks = append(chunks, fmt.Sprintf(`%s="%s"`, key, fields[key]))
+		}
+	}
+
+	return "-- pgweb: " + strings.Join(chunks, " ")
+}
+
+// isParamLine returns true if metadata line declares a parameter
+func isParamLine(content [][]string) bool {
+	for _, field := range content {
+		if field[1] == "param" {
+			return true
+		}
+	}
+	return false
+}
+
+func parseParams(input string) ([]Param, error) {
+	params := []Param{}
+	seenNames := map[string]bool{}
+
+	for _, match := range reMetaPrefix.FindAllStringSubmatch(input, -1) {
+		content := reMetaContent.FindAllStringSubmatch(match[1], -1)
+		if !isParamLine(content) {
+			continue
+		}
+
+		fields := map[string]string{}
+		for _, field := range content {
+			key, value := field[1], field[2]
+
+			if !slices.Contains(allowedParamKeys, key) {
+				return nil, fmt.Errorf("unknown parameter key: %q", key)
+			}
+			if _, ok := fields[key]; ok {
+				return nil, fmt.Errorf("duplicate parameter key: %q", key)
+			}
+			fields[key] = value
+		}
+
+		param, err := newParam(fields)
+		if err != nil {
+			return nil, err
+		}
+		if seenNames[param.Name] {
+			return nil, fmt.Errorf("duplicate parameter: %q", param.Name)
+		}
+		seenNames[param.Name] = true
+
+		params = append(params, param)
+	}
+
+	return params, nil
+}
+
+func newParam(fields map[string]string) (Param, error) {
+	param := Param{
+		Name:        fields["param"],
+		Type:        fields["type"],
+		Default:     fields["default"],
+		Values:      parseTags(fields["values"]),
+		Description: fields["description"],
+	}
+
+	if !reParamName.MatchString(param.Name) {
+		return param, fmt.Errorf("invalid parameter name: %q", param.Name)
+	}
+
+	if param.Type == "" {
+		param.Type = ParamString
+	}
+	if !slices.Contains(allowedParamTypes, param.Type) {
+		return param, fmt.Errorf("invalid parameter %q type: %q", param.Name, param.Type)
+	}
+
+	if fields["required"] != "" {
+		required, err := strconv.ParseBool(fields["required"])
+		if err != nil {
+			return param, fmt.Errorf("invalid parameter %q required value: %q", param.Name, fields["required"])
+		}
+		param.Required = required
+	}
+
+	for _, value := range param.Values {
+		if _, err := param.convert(value); err != nil {
+			return param, fmt.Errorf("invalid parameter %q allowed value: %q", param.Name, value)
+		}
+	}
+
+	if param.Default != "" {
+		if _, err := param.bind(param.Default); err != nil {
+			return param, fmt.Errorf("invalid parameter %q default value: %w", param.Name, err)
+		}
+	}
+
+	return param, nil
+}
@@ -0,0 +1,110 @@
+package queries
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseParams(t *testing.T) {
+	examples := []struct {
+		input  string
+		err    string
+		params []Param
+	}{
+		{input: `-- pgweb: host="localhost"`, params: []Param{}},
+		{
+			input: "-- pgweb: param=\"id\" type=\"integer\" required=\"true\"\n-- pgweb: param=\"status\" default=\"active\" values=\"active, archived\" description=\"Status\"",
+			params: []Param{
+				{Name: "id", Type: "integer", Required: true, Values: []string{}},
+				{Name: "status", Type: "string", Default: "active", Values: []string{"active", "archived"}, Description: "Status"},
+			},
+		},
+		{input: `-- pgweb: param="1id"`, err: `invalid parameter name: "1id"`},
+		{input: `-- pgweb: param="id" type="uuid"`, err: `invalid parameter "id" type: "uuid"`},
+		{input: `-- pgweb: param="id" foo="bar"`, err: `unknown parameter key: "foo"`},
+		{input: `-- pgweb: param="id" type="integer" type="number"`, err: `duplicate parameter key: "type"`},
+		{input: "-- pgweb: param=\"id\"\n-- pgweb: param=\"id\"", err: `duplicate parameter: "id"`},
+		{input: `-- pgweb: param="id" required="yes"`, err: `invalid parameter "id" required value: "yes"`},
+		{input: `-- pgweb: param="id" type="integer" values="1,foo"`, err: `invalid parameter "id" allowed value: "foo"`},
+		{input: `-- pgweb: param="id" type="integer" default="foo"`, err: `invalid parameter "id" default value: parameter "id" must be a valid integer`},
+		{input: `-- pgweb: param="id" default="c" values="a,b"`, err: `invalid parameter "id" default value: parameter "id" must be one of: a, b`},
+	}
+
+	for _, ex := range examples {
+		t.Run(ex.input, func(t *testing.T) {
+			params, err := parseParams(ex.input)
+			if ex.err != "" {
+				assert.EqualError(t, err, ex.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, ex.params, params)
+			}
+		})
+	}
+}
+
+func TestBindParams(t *testing.T) {
+	meta, err := parseMetadata(`
+-- pgweb: host="localhost"
+-- pgweb: param="id" type="integer" required="true"
+-- pgweb: param="price" type="number" default="1.5"
+-- pgweb: param="active" type="boolean"
+-- pgweb: param="day" type="date"
+-- pgweb: param="since" type="timestamp"
+-- pgweb: param="status" values="new,done"
+`)
+	require.NoError(t, err)
+	require.Len(t, meta.Params, 6)
+
+	query := Query{Meta: meta}
+
+	t.Run("valid", func(t *testing.T) {
+		args, err := query.BindParams(map[string]string{
+			"id":     "10",
+			"active": "true",
+			"day":    "2024-01-31",
+			"since":  "2024-01-31 10:00:00",
+			"status": "done",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{
+			int64(10),
+			1.5,
+			true,
+			"2024-01-31",
+			time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC),
+			"done",
+		}, args)
+	})
+
+	t.Run("optional", func(t *testing.T) {
+		args, err := query.BindParams(map[string]string{"id": "1", "active": " "})
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{int64(1), 1.5, nil, nil, nil, nil}, args)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		examples := map[string]map[string]string{
+			`parameter "id" is required`:                   {},
+			`parameter "id" must be a valid integer`:       {"id": "foo"},
+			`parameter "price" must be a valid number`:     {"id": "1", "price": "foo"},
+			`parameter "day" must be a valid date`:         {"id": "1", "day": "2024-13-01"},
+			`parameter "since" must be a valid timestamp`:  {"id": "1", "since": "yesterday"},
+			`parameter "status" must be one of: new, done`: {"id": "1", "status": "foo"},
+		}
+
+		for message, values := range examples {
+			_, err := query.BindParams(values)
+			assert.EqualError(t, err, message)
+		}
+	})
+
+	t.Run("no metadata", func(t *testing.T) {
+		args, err := Query{}.BindParams(nil)
+		assert.NoError(t, err)
+		assert.Nil(t, args)
+	})
+}
@@ -1,5 +1,9 @@
 package queries
 
+import (
+	"github.com/sosedoff/pgweb/pkg/parser"
+)
+
 type Query struct {
 	ID   string
 	Path string
@@ -16,8 +20,20 @@ func (q Query) IsPermitted(host, user, database, mode string) bool {
 
 	meta := q.Meta
 
-	return meta.Host.matches(host) &&
+	matches := meta.Host.matches(host) &&
 		meta.User.matches(user) &&
 		meta.Database.matches(database) &&
 		meta.Mode.matches(mode)
+
+	// Queries that are rejected in read-only mode should not be available either
+	if matches && mode == "readonly" {
+		return q.IsReadOnly()
+	}
+
+	return matches
+}
+
+// IsReadOnly returns true if query is allowed to execute in read-only mode
+func (q Query) IsReadOnly() bool {
+	return parser.CheckReadOnly(q.Data) == nil
 }
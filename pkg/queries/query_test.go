@@ -43,6 +43,30 @@ func TestQueryIsPermitted(t *testing.T) {
 			args:     makeArgs("localhost", "user", "db", "default"),
 			expected: false,
 		},
+		{
+			name:     "match on readonly mode",
+			query:    makeQuery("localhost", "*", "*", "readonly"),
+			args:     makeArgs("localhost", "user", "db", "readonly"),
+			expected: true,
+		},
+		{
+			name:     "match on readonly mode with modifying query",
+			query:    withData(makeQuery("localhost", "*", "*", "*"), "DELETE FROM books"),
+			args:     makeArgs("localhost", "user", "db", "readonly"),
+			expected: false,
+		},
+		{
+			name:     "match on readonly mode with denied function",
+			query:    withData(makeQuery("localhost", "*", "*", "*"), "SELECT pg_terminate_backend(1)"),
+			args:     makeArgs("localhost", "user", "db", "readonly"),
+			expected: false,
+		},
+		{
+			name:     "match on default mode with modifying query",
+			query:    withData(makeQuery("localhost", "*", "*", "*"), "DELETE FROM books"),
+			args:     makeArgs("localhost", "user", "db", "default"),
+			expected: true,
+		},
 	}
 
 	for _, ex := range examples {
@@ -73,5 +97,11 @@ func makeQuery(host, user, database, mode string) Query {
 			Database: mustfield(database),
 			Mode:     mustfield(mode),
 		},
+		Data: "SELECT 1",
 	}
 }
+
+func withData(q Query, data string) Query {
+	q.Data = data
+	return q
+}
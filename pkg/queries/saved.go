@@ -0,0 +1,180 @@
+package queries
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sosedoff/pgweb/pkg/chart"
+)
+
+var (
+	ErrQueryExists    = errors.New("query already exists")
+	ErrInvalidQueryID = errors.New("query id must only contain letters, numbers, dashes and underscores")
+	ErrEmptyQuery     = errors.New("query must not be empty")
+	ErrScheduledQuery = errors.New("scheduled queries could only be changed in the queries directory")
+
+	reQueryID      = regexp.MustCompile(`^[\w\-]+$`)
+	reNonIDChars   = regexp.MustCompile(`[^\w\-]+`)
+	reInvalidValue = regexp.MustCompile(`["\r\n]`)
+)
+
+// SavedQuery contains a query definition that could be written into the store
+type SavedQuery struct {
+	ID          string            `json:"id"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Tags        []string          `json:"tags"`
+	Folder      string            `json:"folder"`
+	Host        string            `json:"host"`
+	User        string            `json:"user"`
+	Database    string            `json:"database"`
+	Mode        string            `json:"mode"`
+	Timeout     int               `json:"timeout"`
+	Params      []Param           `json:"params"`
+	Schedule    string            `json:"schedule"`
+	Bookmark    string            `json:"bookmark"`
+	Alert       string            `json:"alert"`
+	Chart       *chart.Definition `json:"chart"`
+	Query       string            `json:"query"`
+}
+
+// NewSavedQuery returns the query definition for an existing query
+func NewSavedQuery(q Query) SavedQuery {
+	saved := SavedQuery{
+		ID:    q.ID,
+		Query: q.Data,
+	}
+
+	if meta := q.Meta; meta != nil {
+		saved.Title = meta.Title
+		saved.Description = meta.Description
+		saved.Tags = meta.Tags
+		saved.Folder = meta.Folder
+		saved.Host = meta.Host.String()
+		saved.User = meta.User.String()
+		saved.Database = meta.Database.String()
+		saved.Mode = meta.Mode.String()
+		saved.Params = meta.Params
+		saved.Schedule = meta.Schedule
+		saved.Bookmark = meta.Bookmark
+		saved.Chart = meta.Chart
+		if meta.Alert != nil {
+			saved.Alert = meta.Alert.Expression
+		}
+		if meta.Timeout != nil {
+			saved.Timeout = int(meta.Timeout.Seconds())
+		}
+	}
+
+	return saved
+}
+
+// IsScheduled returns true if the query is run by the scheduler with the bookmark credentials
+func (q SavedQuery) IsScheduled() bool {
+	return q.Schedule != "" || q.Bookmark != "" || q.Alert != ""
+}
+
+// Validate checks if the query definition could be saved and read back
+func (q *SavedQuery) Validate() error {
+	if q.ID == "" {
+		q.ID = strings.Trim(reNonIDChars.ReplaceAllString(strings.ToLower(q.Title), "_"), "_")
+	}
+	if !reQueryID.MatchString(q.ID) {
+		return ErrInvalidQueryID
+	}
+	if strings.TrimSpace(q.Query) == "" {
+		return ErrEmptyQuery
+	}
+	if reMetaPrefix.MatchString(q.Query) {
+		return errors.New("query must not contain metadata comments")
+	}
+	if q.Timeout < 0 {
+		return fmt.Errorf(`invalid "timeout" field value: %d`, q.Timeout)
+	}
+
+	for key, value := range q.fields() {
+		if reInvalidValue.MatchString(value) {
+			return fmt.Errorf("%q field must not contain quotes or line breaks", key)
+		}
+	}
+	for _, tag := range q.Tags {
+		if strings.Contains(tag, ",") {
+			return fmt.Errorf("tag %q must not contain commas", tag)
+		}
+	}
+	for _, param := range q.Params {
+		values := []string{param.Name, param.Type, param.Default, param.Description}
+		for _, value := range append(values, param.Values...) {
+			if reInvalidValue.MatchString(value) {
+				return fmt.Errorf("parameter %q fields must not contain quotes or line breaks", param.Name)
+			}
+		}
+		for _, value := range param.Values {
+			if strings.Contains(value, ",") {
+				return fmt.Errorf("parameter %q values must not contain commas", param.Name)
+			}
+		}
+	}
+
+	// Metadata is validated with the same rules as query files
+	meta, err := parseMetadata(q.render())
+	if err == nil && meta == nil {
+		err = errors.New("host field must be set")
+	}
+	return err
+}
+
+// fields returns non-empty metadata fields of the query
+func (q SavedQuery) fields() map[string]string {
+	fields := map[string]string{
+		"title":       q.Title,
+		"description": q.Description,
+		"tags":        strings.Join(q.Tags, ","),
+		"folder":      q.Folder,
+		"host":        q.Host,
+		"user":        q.User,
+		"database":    q.Database,
+		"mode":        q.Mode,
+		"schedule":    q.Schedule,
+		"bookmark":    q.Bookmark,
+		"alert":       q.Alert,
+	}
+	if q.Chart != nil {
+		fields["chart"] = q.Chart.Type
+		fields["chart_x"] = q.Chart.X
+		fields["chart_y"] = strings.Join(q.Chart.Y, ",")
+		fields["chart_series"] = q.Chart.Series
+		fields["chart_aggregate"] = q.Chart.Aggregate
+	}
+	if q.Timeout > 0 {
+		fields["timeout"] = fmt.Sprintf("%d", q.Timeout)
+	}
+
+	for key, value := range fields {
+		if value == "" {
+			delete(fields, key)
+		}
+	}
+
+	return fields
+}
+
+// render returns the query file content, one metadata field per line
+func (q SavedQuery) render() string {
+	fields := q.fields()
+	lines := []string{}
+
+	for _, key := range allowedKeys {
+		if value, ok := fields[key]; ok {
+			lines = append(lines, fmt.Sprintf(`-- pgweb: %s="%s"`, key, value))
+		}
+	}
+	for _, param := range q.Params {
+		lines = append(lines, param.render())
+	}
+	lines = append(lines, strings.TrimSpace(q.Query))
+
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,108 @@
+package queries
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sosedoff/pgweb/pkg/chart"
+)
+
+func TestSavedQueryValidate(t *testing.T) {
+	examples := []struct {
+		name  string
+		query SavedQuery
+		id    string
+		err   string
+	}{
+		{
+			name:  "valid",
+			query: SavedQuery{ID: "foo", Host: "localhost", Query: "SELECT 1"},
+			id:    "foo",
+		},
+		{
+			name:  "id from title",
+			query: SavedQuery{Title: "Daily Sales (EU)", Host: "localhost", Query: "SELECT 1"},
+			id:    "daily_sales_eu",
+		},
+		{
+			name:  "invalid id",
+			query: SavedQuery{ID: "../foo", Host: "localhost", Query: "SELECT 1"},
+			err:   "query id must only contain letters, numbers, dashes and underscores",
+		},
+		{
+			name:  "empty query",
+			query: SavedQuery{ID: "foo", Host: "localhost", Query: " "},
+			err:   "query must not be empty",
+		},
+		{
+			name:  "metadata in query",
+			query: SavedQuery{ID: "foo", Host: "localhost", Query: "-- pgweb: mode=\"*\"\nSELECT 1"},
+			err:   "query must not contain metadata comments",
+		},
+		{
+			name:  "missing host",
+			query: SavedQuery{ID: "foo", Query: "SELECT 1"},
+			err:   "host field must be set",
+		},
+		{
+			name:  "invalid mode",
+			query: SavedQuery{ID: "foo", Host: "localhost", Mode: "foo", Query: "SELECT 1"},
+			err:   `invalid "mode" field value: "foo"`,
+		},
+		{
+			name:  "quotes in title",
+			query: SavedQuery{ID: "foo", Title: `"foo"`, Host: "localhost", Query: "SELECT 1"},
+			err:   `"title" field must not contain quotes or line breaks`,
+		},
+		{
+			name:  "comma in tag",
+			query: SavedQuery{ID: "foo", Tags: []string{"a,b"}, Host: "localhost", Query: "SELECT 1"},
+			err:   `tag "a,b" must not contain commas`,
+		},
+	}
+
+	for _, ex := range examples {
+		t.Run(ex.name, func(t *testing.T) {
+			err := ex.query.Validate()
+			if ex.err != "" {
+				assert.EqualError(t, err, ex.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, ex.id, ex.query.ID)
+			}
+		})
+	}
+}
+
+func TestSavedQueryRender(t *testing.T) {
+	query := SavedQuery{
+		ID:          "foo",
+		Title:       "Foo",
+		Description: "Foo query",
+		Tags:        []string{"a", "b"},
+		Folder:      "reports",
+		Host:        "localhost",
+		Mode:        "readonly",
+		Timeout:     10,
+		Params:      []Param{{Name: "id", Type: "integer", Required: true}},
+		Chart:       &chart.Definition{Type: "bar", X: "day", Y: []string{"a", "b"}, Aggregate: "sum"},
+		Query:       "SELECT $1::int\n",
+	}
+
+	expected := `-- pgweb: title="Foo"
+-- pgweb: description="Foo query"
+-- pgweb: tags="a,b"
+-- pgweb: folder="reports"
+-- pgweb: host="localhost"
+-- pgweb: mode="readonly"
+-- pgweb: timeout="10"
+-- pgweb: chart="bar"
+-- pgweb: chart_x="day"
+-- pgweb: chart_y="a,b"
+-- pgweb: chart_aggregate="sum"
+-- pgweb: param="id" type="integer" required="true"
+SELECT $1::int
+`
+	assert.Equal(t, expected, query.render())
+}
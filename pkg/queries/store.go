@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
@@ -14,7 +15,9 @@ var (
 )
 
 type Store struct {
-	dir string
+	dir   string
+	git   *gitRepo
+	index *index
 }
 
 func NewStore(dir string) *Store {
@@ -23,12 +26,157 @@ func NewStore(dir string) *Store {
 	}
 }
 
+// NewGitStore returns a store backed by the git repository of the queries directory.
+// Files are read from the branch or tag when ref is set, otherwise from the working tree.
+func NewGitStore(dir string, ref string) (*Store, error) {
+	repo, err := newGitRepo(dir, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		dir: dir,
+		git: repo,
+	}, nil
+}
+
+// IsGit returns true if the store is backed by the git repository
+func (s Store) IsGit() bool {
+	return s.git != nil
+}
+
+// IsReadOnly returns true if queries are read from the git revision
+func (s Store) IsReadOnly() bool {
+	return s.git != nil && s.git.ref != ""
+}
+
 func (s Store) Read(id string) (*Query, error) {
-	path := filepath.Join(s.dir, fmt.Sprintf("%s.sql", id))
-	return readQuery(path)
+	if !reQueryID.MatchString(id) {
+		return nil, ErrQueryFileNotExist
+	}
+	if files := s.indexedFiles(); files != nil {
+		return files.query(id)
+	}
+	return s.readQuery(id + ".sql")
+}
+
+// Create writes a new query file
+func (s Store) Create(query SavedQuery) (*Query, error) {
+	if s.IsReadOnly() {
+		return nil, ErrStoreReadOnly
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(s.path(query.ID)); err == nil {
+		return nil, ErrQueryExists
+	}
+
+	return s.write(query)
+}
+
+// Update replaces an existing query file, the query is renamed when its id changes
+func (s Store) Update(id string, query SavedQuery) (*Query, error) {
+	if s.IsReadOnly() {
+		return nil, ErrStoreReadOnly
+	}
+	if _, err := s.Read(id); err != nil {
+		return nil, err
+	}
+
+	if query.ID == "" {
+		query.ID = id
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	if query.ID != id {
+		if _, err := os.Stat(s.path(query.ID)); err == nil {
+			return nil, ErrQueryExists
+		}
+	}
+
+	result, err := s.write(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.ID != id {
+		if err := os.Remove(s.path(id)); err != nil {
+			return nil, err
+		}
+		s.Reload()
+	}
+
+	return result, nil
+}
+
+// Delete removes the query file
+func (s Store) Delete(id string) error {
+	if s.IsReadOnly() {
+		return ErrStoreReadOnly
+	}
+	if !reQueryID.MatchString(id) {
+		return ErrQueryFileNotExist
+	}
+
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrQueryFileNotExist
+	}
+	if err == nil {
+		s.Reload()
+	}
+	return err
+}
+
+func (s Store) path(id string) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s.sql", id))
+}
+
+// write saves the query file atomically and reads it back
+func (s Store) write(query SavedQuery) (*Query, error) {
+	tmp, err := os.CreateTemp(s.dir, ".query-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.WriteString(query.render())
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), s.path(query.ID)); err != nil {
+		return nil, err
+	}
+	s.Reload()
+
+	return s.Read(query.ID)
 }
 
 func (s Store) ReadAll() ([]Query, error) {
+	files := s.allFiles()
+	if files.err != nil {
+		return nil, files.err
+	}
+	return slices.Clone(files.queries), nil
+}
+
+// listFiles returns names of the files in the queries directory
+func (s Store) listFiles() ([]string, error) {
+	if s.IsReadOnly() {
+		return s.git.listFiles()
+	}
+
 	entries, err := os.ReadDir(s.dir)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -37,38 +185,37 @@ func (s Store) ReadAll() ([]Query, error) {
 		return nil, err
 	}
 
-	queries := []Query{}
-
+	names := []string{}
 	for _, entry := range entries {
-		name := entry.Name()
-		if filepath.Ext(name) != ".sql" {
-			continue
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
 		}
+	}
 
-		path := filepath.Join(s.dir, name)
-		query, err := readQuery(path)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "[WARN] skipping %q query file due to error: %v\n", name, err)
-			continue
-		}
-		if query == nil {
-			continue
-		}
+	return names, nil
+}
 
-		queries = append(queries, *query)
+// readFile reads the file of the queries directory, from the git revision if configured
+func (s Store) readFile(name string) ([]byte, error) {
+	if s.IsReadOnly() {
+		return s.git.readFile(s.git.ref, name)
 	}
-
-	return queries, nil
+	return os.ReadFile(filepath.Join(s.dir, name))
 }
 
-func readQuery(path string) (*Query, error) {
-	data, err := os.ReadFile(path)
+func (s Store) readQuery(name string) (*Query, error) {
+	data, err := s.readFile(name)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return nil, ErrQueryFileNotExist
 		}
 		return nil, err
 	}
+
+	return parseQuery(filepath.Join(s.dir, name), data)
+}
+
+func parseQuery(path string, data []byte) (*Query, error) {
 	dataStr := string(data)
 
 	meta, err := parseMetadata(dataStr)
@@ -3,9 +3,12 @@
 package queries
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestStoreReadAll(t *testing.T) {
@@ -69,3 +72,127 @@ func TestStoreRead(t *testing.T) {
 		})
 	}
 }
+
+func TestStoreWrite(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	input := SavedQuery{
+		ID:     "foo",
+		Title:  "Foo",
+		Tags:   []string{"daily"},
+		Folder: "reports",
+		Host:   "localhost",
+		Query:  "SELECT 1",
+	}
+
+	t.Run("create", func(t *testing.T) {
+		query, err := store.Create(input)
+		require.NoError(t, err)
+		assert.Equal(t, "foo", query.ID)
+		assert.Equal(t, "SELECT 1", query.Data)
+		assert.Equal(t, []string{"daily"}, query.Meta.Tags)
+		assert.Equal(t, "reports", query.Meta.Folder)
+
+		saved := NewSavedQuery(*query)
+		assert.Equal(t, "*", saved.User)
+		assert.Equal(t, "Foo", saved.Title)
+
+		_, err = store.Create(input)
+		assert.Equal(t, ErrQueryExists, err)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		input.Query = "SELECT 2"
+		query, err := store.Update("foo", input)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT 2", query.Data)
+
+		_, err = store.Update("bar", input)
+		assert.Equal(t, ErrQueryFileNotExist, err)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		input.ID = "bar"
+		query, err := store.Update("foo", input)
+		require.NoError(t, err)
+		assert.Equal(t, "bar", query.ID)
+
+		_, err = store.Read("foo")
+		assert.Equal(t, ErrQueryFileNotExist, err)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.NoError(t, store.Delete("bar"))
+		assert.Equal(t, ErrQueryFileNotExist, store.Delete("bar"))
+		assert.Equal(t, ErrQueryFileNotExist, store.Delete("../bar"))
+
+		queries, err := store.ReadAll()
+		require.NoError(t, err)
+		assert.Empty(t, queries)
+	})
+}
+
+func TestStoreErrors(t *testing.T) {
+	errs, err := NewStore("../../data").Errors()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"lc_invalid_meta.sql": `invalid "mode" field value: "foo"`,
+	}, errs)
+
+	_, err = NewStore("../../data2").Errors()
+	assert.Equal(t, ErrQueryDirNotExist, err)
+}
+
+func TestStoreIndex(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("foo.sql", "-- pgweb: host=\"localhost\"\nselect 1")
+	write("no_meta.sql", "select 1")
+
+	store := NewStore(dir)
+	store.EnableIndex()
+
+	queries, err := store.ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, queries, 1)
+
+	query, err := store.Read("no_meta")
+	assert.NoError(t, err)
+	assert.Nil(t, query)
+
+	// Index is not updated until reloaded
+	write("bar.sql", "-- pgweb: host=\"localhost\"\nselect 2")
+	write("invalid.sql", "-- pgweb: host=\"localhost\" mode=\"foo\"\nselect 3")
+	write("main.dashboard.toml", "[[panels]]\nquery = \"foo\"\n")
+
+	_, err = store.Read("bar")
+	assert.Equal(t, ErrQueryFileNotExist, err)
+
+	store.Reload()
+
+	query, err = store.Read("bar")
+	require.NoError(t, err)
+	assert.Equal(t, "select 2", query.Data)
+
+	_, err = store.Read("invalid")
+	assert.EqualError(t, err, `invalid "mode" field value: "foo"`)
+
+	dashboard, err := store.ReadDashboard("main")
+	require.NoError(t, err)
+	assert.Equal(t, "foo", dashboard.Panels[0].Query)
+
+	errs, err := store.Errors()
+	assert.NoError(t, err)
+	assert.Len(t, errs, 1)
+
+	// Index is updated after writes
+	_, err = store.Create(SavedQuery{ID: "baz", Host: "localhost", Query: "select 4"})
+	require.NoError(t, err)
+	require.NoError(t, store.Delete("foo"))
+
+	queries, err = store.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bar", "baz"}, []string{queries[0].ID, queries[1].ID})
+}
@@ -0,0 +1,256 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sosedoff/pgweb/pkg/client"
+	"github.com/sosedoff/pgweb/pkg/queries"
+)
+
+// Maximum number of result rows included into the alert notification
+const alertExcerptRows = 5
+
+// Notification contains details of the triggered alert
+type Notification struct {
+	QueryID   string       `json:"query_id"`
+	Title     string       `json:"title"`
+	Bookmark  string       `json:"bookmark"`
+	Alert     string       `json:"alert"`
+	RunID     string       `json:"run_id"`
+	FiredAt   time.Time    `json:"fired_at"`
+	RowsCount int          `json:"rows_count"`
+	Columns   []string     `json:"columns"`
+	Rows      []client.Row `json:"rows"`
+}
+
+// Notifier delivers alert notifications
+type Notifier interface {
+	Notify(notification Notification) error
+}
+
+// Alerter evaluates alert conditions after scheduled runs. Notification is sent
+// once the condition is met, repeat alerts are suppressed until the condition clears.
+type Alerter struct {
+	notifier  Notifier
+	statePath string
+	firing    map[string]bool // Keyed by query id and alert expression
+	mu        sync.Mutex
+}
+
+// NewAlerter returns a new alerter, state of firing alerts is persisted into the given file
+func NewAlerter(notifier Notifier, statePath string) (*Alerter, error) {
+	alerter := &Alerter{
+		notifier:  notifier,
+		statePath: statePath,
+		firing:    map[string]bool{},
+	}
+
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return alerter, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &alerter.firing); err != nil {
+		return nil, fmt.Errorf("invalid alerts state file: %w", err)
+	}
+
+	return alerter, nil
+}
+
+// Check evaluates the query alert against the run result and sends the notification
+// when the alert starts firing. Returns true if notification has been sent.
+func (a *Alerter) Check(query queries.Query, run *Run) (bool, error) {
+	alert := query.Meta.Alert
+	if alert == nil || !run.Success || run.Result == nil {
+		return false, nil
+	}
+
+	triggered, rows, err := evaluateAlert(alert, run.Result)
+	if err != nil {
+		return false, err
+	}
+
+	key := alertKey(query)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !triggered {
+		if a.firing[key] {
+			delete(a.firing, key)
+			return false, a.saveState()
+		}
+		return false, nil
+	}
+
+	if a.firing[key] {
+		return false, nil
+	}
+
+	err = a.notifier.Notify(Notification{
+		QueryID:   query.ID,
+		Title:     query.Meta.Title,
+		Bookmark:  run.Bookmark,
+		Alert:     alert.Expression,
+		RunID:     run.ID,
+		FiredAt:   run.FinishedAt,
+		RowsCount: run.RowsCount,
+		Columns:   run.Result.Columns,
+		Rows:      rows,
+	})
+	if err != nil {
+		// Notification will be retried after the next run
+		return false, err
+	}
+
+	a.firing[key] = true
+	return true, a.saveState()
+}
+
+// Prune removes state of alerts that are no longer scheduled, including queries that
+// were removed or had their alert expression changed.
+func (a *Alerter) Prune(storeQueries []queries.Query) error {
+	keys := map[string]bool{}
+	for _, query := range storeQueries {
+		if query.Meta.Schedule != "" && query.Meta.Alert != nil {
+			keys[alertKey(query)] = true
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	changed := false
+	for key := range a.firing {
+		if !keys[key] {
+			delete(a.firing, key)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return a.saveState()
+}
+
+// alertKey returns the key of the alert state, changed alert expression starts with a clear state
+func alertKey(query queries.Query) string {
+	return query.ID + ":" + query.Meta.Alert.Expression
+}
+
+func (a *Alerter) saveState() error {
+	if a.statePath == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(a.firing)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(a.statePath), 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(a.statePath, data, 0600)
+}
+
+// evaluateAlert returns true if the alert condition is met along with the result excerpt.
+// Column conditions are met when any of the result rows matches.
+func evaluateAlert(alert *queries.Alert, result *client.Result) (bool, []client.Row, error) {
+	if alert.Left.Type == queries.AlertOperandRows {
+		matched := compareValues(strconv.Itoa(len(result.Rows)), alert.Operator, alert.Right.Value)
+		return matched, excerpt(result.Rows), nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range result.Columns {
+		columns[name] = i
+	}
+
+	for _, operand := range []queries.AlertOperand{alert.Left, alert.Right} {
+		if operand.Type != queries.AlertOperandColumn {
+			continue
+		}
+		if _, ok := columns[operand.Value]; !ok {
+			return false, nil, fmt.Errorf("alert column %q is not found in the result", operand.Value)
+		}
+	}
+
+	matches := []client.Row{}
+	for _, row := range result.Rows {
+		left := operandValue(alert.Left, row, columns)
+		right := operandValue(alert.Right, row, columns)
+
+		if compareValues(left, alert.Operator, right) {
+			matches = append(matches, row)
+		}
+	}
+
+	return len(matches) > 0, excerpt(matches), nil
+}
+
+func operandValue(operand queries.AlertOperand, row client.Row, columns map[string]int) string {
+	if operand.Type != queries.AlertOperandColumn {
+		return operand.Value
+	}
+
+	value := row[columns[operand.Value]]
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// compareValues compares values as numbers when possible, otherwise as strings
+func compareValues(left string, operator string, right string) bool {
+	cmp := strings.Compare(left, right)
+
+	leftNum, leftErr := strconv.ParseFloat(left, 64)
+	rightNum, rightErr := strconv.ParseFloat(right, 64)
+	if leftErr == nil && rightErr == nil {
+		switch {
+		case leftNum < rightNum:
+			cmp = -1
+		case leftNum > rightNum:
+			cmp = 1
+		default:
+			cmp = 0
+		}
+	}
+
+	switch operator {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	}
+
+	return false
+}
+
+func excerpt(rows []client.Row) []client.Row {
+	if len(rows) > alertExcerptRows {
+		return rows[:alertExcerptRows]
+	}
+	return rows
+}
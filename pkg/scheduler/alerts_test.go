@@ -0,0 +1,198 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sosedoff/pgweb/pkg/client"
+	"github.com/sosedoff/pgweb/pkg/queries"
+)
+
+type testNotifier struct {
+	notifications []Notification
+	err           error
+}
+
+func (n *testNotifier) Notify(notification Notification) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func alertQuery(t *testing.T, expression string) queries.Query {
+	query, err := readTestQuery(t, `-- pgweb: host="localhost" schedule="@hourly" bookmark="db" alert="`+expression+`"`)
+	require.NoError(t, err)
+	return *query
+}
+
+func readTestQuery(t *testing.T, content string) (*queries.Query, error) {
+	dir := t.TempDir()
+	writeQuery(t, dir, "foo", content+"\nSELECT 1")
+	return queries.NewStore(dir).Read("foo")
+}
+
+func TestEvaluateAlert(t *testing.T) {
+	result := &client.Result{
+		Columns: []string{"name", "used", "quota", "status"},
+		Rows: []client.Row{
+			{"a", int64(5), int64(10), "ok"},
+			{"b", int64(20), int64(10), "failed"},
+			{"c", 15.5, int64(10), nil},
+		},
+	}
+
+	examples := []struct {
+		expression string
+		triggered  bool
+		rows       int
+		err        string
+	}{
+		{expression: "rows > 0", triggered: true, rows: 3},
+		{expression: "rows >= 4", triggered: false, rows: 3},
+		{expression: "used > quota", triggered: true, rows: 2},
+		{expression: "used > 100", triggered: false, rows: 0},
+		{expression: "used <= 5", triggered: true, rows: 1},
+		{expression: "status = 'failed'", triggered: true, rows: 1},
+		{expression: "status != 'ok'", triggered: true, rows: 2},
+		{expression: "missing > 1", err: `alert column "missing" is not found in the result`},
+	}
+
+	for _, ex := range examples {
+		t.Run(ex.expression, func(t *testing.T) {
+			query := alertQuery(t, ex.expression)
+
+			triggered, rows, err := evaluateAlert(query.Meta.Alert, result)
+			if ex.err != "" {
+				assert.EqualError(t, err, ex.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, ex.triggered, triggered)
+			assert.Len(t, rows, ex.rows)
+		})
+	}
+}
+
+func TestAlerter(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "alerts.json")
+	notifier := &testNotifier{}
+
+	alerter, err := NewAlerter(notifier, statePath)
+	require.NoError(t, err)
+
+	query := alertQuery(t, "rows > 0")
+	found := &Run{ID: "1", Bookmark: "db", Success: true, RowsCount: 1, Result: &client.Result{Columns: []string{"id"}, Rows: []client.Row{{1}}}}
+	empty := &Run{ID: "2", Bookmark: "db", Success: true, Result: &client.Result{Columns: []string{"id"}, Rows: []client.Row{}}}
+	failed := &Run{ID: "3", Bookmark: "db", Success: false}
+
+	check := func(run *Run) bool {
+		sent, err := alerter.Check(query, run)
+		require.NoError(t, err)
+		return sent
+	}
+
+	assert.True(t, check(found))
+	assert.False(t, check(found), "repeat alert must be suppressed")
+	assert.False(t, check(failed), "failed runs do not change alert state")
+	assert.False(t, check(found))
+
+	// State is persisted between restarts
+	alerter, err = NewAlerter(notifier, statePath)
+	require.NoError(t, err)
+	assert.False(t, check(found))
+
+	assert.False(t, check(empty))
+	assert.True(t, check(found), "alert must fire again once the condition clears")
+
+	require.Len(t, notifier.notifications, 2)
+	assert.Equal(t, "foo", notifier.notifications[0].QueryID)
+	assert.Equal(t, "rows > 0", notifier.notifications[0].Alert)
+	assert.Equal(t, "db", notifier.notifications[0].Bookmark)
+
+	t.Run("notification error", func(t *testing.T) {
+		alerter, err := NewAlerter(&testNotifier{err: errors.New("failed")}, "")
+		require.NoError(t, err)
+
+		_, err = alerter.Check(query, found)
+		assert.EqualError(t, err, "failed")
+		assert.False(t, alerter.firing["foo:rows > 0"])
+	})
+
+	t.Run("changed expression", func(t *testing.T) {
+		alerter, err := NewAlerter(&testNotifier{}, "")
+		require.NoError(t, err)
+
+		sent, err := alerter.Check(query, found)
+		require.NoError(t, err)
+		assert.True(t, sent)
+
+		sent, err = alerter.Check(alertQuery(t, "rows >= 1"), found)
+		require.NoError(t, err)
+		assert.True(t, sent, "changed alert must fire on its own")
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		alerter, err := NewAlerter(&testNotifier{}, statePath)
+		require.NoError(t, err)
+		assert.True(t, alerter.firing["foo:rows > 0"])
+
+		require.NoError(t, alerter.Prune([]queries.Query{alertQuery(t, "rows >= 1")}))
+		assert.Empty(t, alerter.firing)
+
+		alerter, err = NewAlerter(&testNotifier{}, statePath)
+		require.NoError(t, err)
+		assert.Empty(t, alerter.firing)
+	})
+}
+
+func TestWebhook(t *testing.T) {
+	var body map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &body) //nolint
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(500)
+		}
+	}))
+	defer server.Close()
+
+	notification := Notification{
+		QueryID:   "foo",
+		Title:     "Foo",
+		Bookmark:  "db",
+		Alert:     "rows > 0",
+		RowsCount: 1,
+		Columns:   []string{"id", "name"},
+		Rows:      []client.Row{{1, "bar"}},
+	}
+
+	_, err := NewWebhook(server.URL, "xml")
+	assert.EqualError(t, err, `invalid webhook format: "xml"`)
+
+	webhook, err := NewWebhook(server.URL, "")
+	require.NoError(t, err)
+	require.NoError(t, webhook.Notify(notification))
+	assert.Equal(t, "foo", body["query_id"])
+	assert.Equal(t, "rows > 0", body["alert"])
+
+	webhook, err = NewWebhook(server.URL, WebhookFormatSlack)
+	require.NoError(t, err)
+	require.NoError(t, webhook.Notify(notification))
+	assert.Equal(t, "*Alert: Foo*\nCondition `rows > 0` is met for bookmark `db` (1 rows)\n```\nid | name\n1 | bar\n```", body["text"])
+
+	webhook, err = NewWebhook(server.URL+"/fail", WebhookFormatJSON)
+	require.NoError(t, err)
+	assert.EqualError(t, webhook.Notify(notification), "webhook responded with status 500")
+}
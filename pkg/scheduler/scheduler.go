@@ -0,0 +1,236 @@
+package scheduler
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+
+	"github.com/sosedoff/pgweb/pkg/bookmarks"
+	"github.com/sosedoff/pgweb/pkg/client"
+	"github.com/sosedoff/pgweb/pkg/queries"
+)
+
+var (
+	ErrQueryNotScheduled = errors.New("query is not scheduled")
+	ErrQueryNotPermitted = errors.New("query is not permitted for the bookmark connection")
+)
+
+// Scheduler runs local queries with a schedule against their bookmarks
+// and saves the results as snapshots.
+type Scheduler struct {
+	queries   *queries.Store
+	bookmarks bookmarks.Manager
+	snapshots *SnapshotStore
+	alerter   *Alerter
+	logger    *logrus.Logger
+	cron      *cron.Cron
+	entries   map[string]cron.EntryID
+	mu        sync.Mutex
+}
+
+func New(store *queries.Store, manager bookmarks.Manager, snapshots *SnapshotStore, logger *logrus.Logger) *Scheduler {
+	return &Scheduler{
+		queries:   store,
+		bookmarks: manager,
+		snapshots: snapshots,
+		logger:    logger,
+		entries:   map[string]cron.EntryID{},
+		cron: cron.New(
+			cron.WithLogger(cron.PrintfLogger(logger)),
+			cron.WithChain(cron.SkipIfStillRunning(cron.PrintfLogger(logger))),
+		),
+	}
+}
+
+// Start schedules all local queries and starts the scheduler in the background
+func (s *Scheduler) Start() error {
+	if err := s.Reload(); err != nil {
+		return err
+	}
+
+	s.cron.Start()
+	return nil
+}
+
+// Stop stops the scheduler and waits for the running queries to complete
+func (s *Scheduler) Stop() {
+	<-s.cron.Stop().Done()
+}
+
+// Reload replaces scheduled entries with the current local queries
+func (s *Scheduler) Reload() error {
+	storeQueries, err := s.queries.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, entryID := range s.entries {
+		s.cron.Remove(entryID)
+		delete(s.entries, id)
+	}
+
+	for _, query := range storeQueries {
+		if query.Meta.Schedule == "" {
+			continue
+		}
+
+		id := query.ID
+		entryID, err := s.cron.AddFunc(query.Meta.Schedule, func() {
+			s.RunByID(id) //nolint
+		})
+		if err != nil {
+			s.logger.WithError(err).WithField("query_id", id).Error("unable to schedule query")
+			continue
+		}
+
+		s.entries[id] = entryID
+		s.logger.WithField("query_id", id).WithField("schedule", query.Meta.Schedule).Debug("scheduled query")
+	}
+
+	if s.alerter != nil {
+		if err := s.alerter.Prune(storeQueries); err != nil {
+			s.logger.WithError(err).Error("unable to save alerts state")
+		}
+	}
+
+	return nil
+}
+
+// SetAlerter configures evaluation of query alerts after each run
+func (s *Scheduler) SetAlerter(alerter *Alerter) {
+	s.alerter = alerter
+}
+
+// Snapshots returns the store of scheduled query runs
+func (s *Scheduler) Snapshots() *SnapshotStore {
+	return s.snapshots
+}
+
+// NextRun returns the next scheduled run time of the query
+func (s *Scheduler) NextRun(id string) *time.Time {
+	s.mu.Lock()
+	entryID, ok := s.entries[id]
+	s.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	next := s.cron.Entry(entryID).Next
+	if next.IsZero() {
+		return nil
+	}
+
+	return &next
+}
+
+// RunByID reads the latest version of the query and runs it
+func (s *Scheduler) RunByID(id string) (*Run, error) {
+	query, err := s.queries.Read(id)
+	if err != nil {
+		return nil, err
+	}
+	if query == nil || query.Meta.Schedule == "" {
+		return nil, ErrQueryNotScheduled
+	}
+
+	return s.Run(*query), nil
+}
+
+// Run executes the query against its bookmark and saves the run snapshot
+func (s *Scheduler) Run(query queries.Query) *Run {
+	startedAt := time.Now()
+
+	run := &Run{
+		ID:        newRunID(startedAt),
+		QueryID:   query.ID,
+		Title:     query.Meta.Title,
+		Bookmark:  query.Meta.Bookmark,
+		StartedAt: startedAt,
+	}
+
+	result, err := s.execute(query)
+
+	run.FinishedAt = time.Now()
+	run.Duration = run.FinishedAt.Sub(startedAt).Milliseconds()
+	run.Success = err == nil
+	run.Result = result
+
+	if err != nil {
+		run.Error = err.Error()
+	}
+	if result != nil {
+		run.RowsCount = len(result.Rows)
+	}
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"query_id":    run.QueryID,
+		"bookmark":    run.Bookmark,
+		"duration_ms": run.Duration,
+		"rows_count":  run.RowsCount,
+	})
+	if err != nil {
+		logger.WithError(err).Warn("scheduled query failed")
+	} else {
+		logger.Info("scheduled query finished")
+	}
+
+	// Snapshots include the chart data, the run itself is not failed if the chart is invalid
+	if result != nil && query.Meta.Chart != nil {
+		if result.Chart, err = result.BuildChart(*query.Meta.Chart); err != nil {
+			logger.WithError(err).Warn("unable to build scheduled query chart")
+		}
+	}
+
+	if err := s.snapshots.Save(run); err != nil {
+		logger.WithError(err).Error("unable to save scheduled query snapshot")
+	}
+
+	if s.alerter != nil {
+		sent, err := s.alerter.Check(query, run)
+		if err != nil {
+			logger.WithError(err).Error("unable to process scheduled query alert")
+		}
+		if sent {
+			logger.WithField("alert", query.Meta.Alert.Expression).Info("scheduled query alert sent")
+		}
+	}
+
+	return run
+}
+
+func (s *Scheduler) execute(query queries.Query) (*client.Result, error) {
+	bookmark, err := s.bookmarks.Get(query.Meta.Bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := client.NewFromBookmark(bookmark)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// Query metadata restrictions apply to the bookmark connection as well
+	connCtx, err := conn.GetConnContext()
+	if err != nil {
+		return nil, err
+	}
+	if !query.IsPermitted(connCtx.Host, connCtx.User, connCtx.Database, connCtx.Mode) {
+		return nil, ErrQueryNotPermitted
+	}
+
+	// Scheduled queries could only use parameter defaults
+	args, err := query.BindParams(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return conn.QueryAll(query.Data, args...)
+}
@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sosedoff/pgweb/pkg/bookmarks"
+	"github.com/sosedoff/pgweb/pkg/queries"
+)
+
+func writeQuery(t *testing.T, dir string, id string, content string) {
+	require.NoError(t, os.WriteFile(filepath.Join(dir, id+".sql"), []byte(content), 0644))
+}
+
+func TestScheduler(t *testing.T) {
+	dir := t.TempDir()
+	writeQuery(t, dir, "scheduled", "-- pgweb: host=\"localhost\" schedule=\"@hourly\" bookmark=\"missing\"\nSELECT 1")
+	writeQuery(t, dir, "regular", "-- pgweb: host=\"localhost\"\nSELECT 1")
+
+	s := New(queries.NewStore(dir), bookmarks.NewManager(t.TempDir()), NewSnapshotStore(t.TempDir(), 10), logrus.New())
+
+	t.Run("reload", func(t *testing.T) {
+		require.NoError(t, s.Reload())
+		assert.Len(t, s.entries, 1)
+		assert.Contains(t, s.entries, "scheduled")
+
+		// Reloading replaces existing entries
+		require.NoError(t, s.Reload())
+		assert.Len(t, s.entries, 1)
+		assert.Len(t, s.cron.Entries(), 1)
+	})
+
+	t.Run("next run", func(t *testing.T) {
+		s.cron.Start()
+		defer s.Stop()
+
+		assert.NotNil(t, s.NextRun("scheduled"))
+		assert.Nil(t, s.NextRun("regular"))
+	})
+
+	t.Run("run", func(t *testing.T) {
+		_, err := s.RunByID("regular")
+		assert.Equal(t, ErrQueryNotScheduled, err)
+
+		_, err = s.RunByID("foo")
+		assert.Equal(t, queries.ErrQueryFileNotExist, err)
+
+		run, err := s.RunByID("scheduled")
+		require.NoError(t, err)
+		assert.False(t, run.Success)
+		assert.Equal(t, "bookmark missing not found", run.Error)
+		assert.Equal(t, "missing", run.Bookmark)
+
+		runs, total, err := s.Snapshots().List("scheduled", 0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, run.ID, runs[0].ID)
+	})
+}
@@ -0,0 +1,243 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sosedoff/pgweb/pkg/client"
+)
+
+const (
+	runIDFormat      = "20060102T150405.000000000Z"
+	resultFileSuffix = ".result.json"
+)
+
+var (
+	ErrRunNotFound = errors.New("run not found")
+
+	reRunID   = regexp.MustCompile(`^\d{8}T\d{6}\.\d{9}Z$`)
+	reQueryID = regexp.MustCompile(`^[\w\-]+$`)
+)
+
+// Run contains the outcome of a single scheduled query execution
+type Run struct {
+	ID         string         `json:"id"`
+	QueryID    string         `json:"query_id"`
+	Title      string         `json:"title,omitempty"`
+	Bookmark   string         `json:"bookmark"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Duration   int64          `json:"duration_ms"`
+	Success    bool           `json:"success"`
+	Error      string         `json:"error,omitempty"`
+	RowsCount  int            `json:"rows_count"`
+	Result     *client.Result `json:"result,omitempty"`
+}
+
+// SnapshotStore keeps scheduled runs on disk grouped into a directory per query.
+// Run details and results are written into separate JSON files, so runs could
+// be listed without reading the results.
+type SnapshotStore struct {
+	dir      string
+	limit    int
+	rowLimit int
+}
+
+func NewSnapshotStore(dir string, limit int) *SnapshotStore {
+	return &SnapshotStore{
+		dir:   dir,
+		limit: limit,
+	}
+}
+
+// SetRowLimit sets the maximum number of result rows kept in the snapshot, 0 for no limit
+func (s *SnapshotStore) SetRowLimit(limit int) {
+	s.rowLimit = limit
+}
+
+// Save writes the run snapshot and removes the oldest snapshots over the limit
+func (s *SnapshotStore) Save(run *Run) error {
+	if !reQueryID.MatchString(run.QueryID) || !reRunID.MatchString(run.ID) {
+		return ErrRunNotFound
+	}
+
+	dir := filepath.Join(s.dir, run.QueryID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	// Result is written first, the run is listed once its details are written
+	if run.Result != nil {
+		if err := writeJSONFile(filepath.Join(dir, run.ID+resultFileSuffix), s.truncate(run.Result)); err != nil {
+			return err
+		}
+	}
+
+	details := *run
+	details.Result = nil
+	if err := writeJSONFile(filepath.Join(dir, run.ID+".json"), details); err != nil {
+		return err
+	}
+
+	return s.prune(run.QueryID)
+}
+
+// List returns the query runs without results, newest first, and the total number of runs
+func (s *SnapshotStore) List(queryID string, offset int, limit int) ([]Run, int, error) {
+	ids, err := s.runIDs(queryID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := len(ids)
+	start := min(offset, total)
+	end := total
+	if limit > 0 {
+		end = min(start+limit, total)
+	}
+
+	runs := []Run{}
+	for _, id := range ids[start:end] {
+		run := Run{}
+		if err := readJSONFile(filepath.Join(s.dir, queryID, id+".json"), &run); err != nil {
+			return nil, 0, err
+		}
+		run.Result = nil
+		runs = append(runs, run)
+	}
+
+	return runs, total, nil
+}
+
+// Get returns the query run with the result
+func (s *SnapshotStore) Get(queryID string, id string) (*Run, error) {
+	if !reQueryID.MatchString(queryID) || !reRunID.MatchString(id) {
+		return nil, ErrRunNotFound
+	}
+
+	run := &Run{}
+	if err := readJSONFile(filepath.Join(s.dir, queryID, id+".json"), run); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrRunNotFound
+		}
+		return nil, err
+	}
+
+	result := &client.Result{}
+	err := readJSONFile(filepath.Join(s.dir, queryID, id+resultFileSuffix), result)
+	if err == nil {
+		run.Result = result
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// truncate returns a copy of the result with at most rowLimit rows
+func (s *SnapshotStore) truncate(result *client.Result) *client.Result {
+	if s.rowLimit <= 0 || len(result.Rows) <= s.rowLimit {
+		return result
+	}
+
+	truncated := *result
+	truncated.Rows = result.Rows[:s.rowLimit]
+	if result.Stats != nil {
+		stats := *result.Stats
+		stats.RowsCount = s.rowLimit
+		stats.Truncated = true
+		truncated.Stats = &stats
+	}
+
+	return &truncated
+}
+
+// runIDs returns the query run ids, newest first
+func (s *SnapshotStore) runIDs(queryID string) ([]string, error) {
+	if !reQueryID.MatchString(queryID) {
+		return []string{}, nil
+	}
+
+	entries, err := os.ReadDir(filepath.Join(s.dir, queryID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, entry := range entries {
+		id := strings.TrimSuffix(entry.Name(), ".json")
+		if reRunID.MatchString(id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+
+	return ids, nil
+}
+
+func (s *SnapshotStore) prune(queryID string) error {
+	if s.limit <= 0 {
+		return nil
+	}
+
+	ids, err := s.runIDs(queryID)
+	if err != nil || len(ids) <= s.limit {
+		return err
+	}
+
+	for _, id := range ids[s.limit:] {
+		if err := os.Remove(filepath.Join(s.dir, queryID, id+".json")); err != nil {
+			return err
+		}
+		if err := os.Remove(filepath.Join(s.dir, queryID, id+resultFileSuffix)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newRunID(t time.Time) string {
+	return t.UTC().Format(runIDFormat)
+}
+
+// writeJSONFile atomically replaces the file with the encoded value
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".run-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func readJSONFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sosedoff/pgweb/pkg/client"
+)
+
+func TestSnapshotStore(t *testing.T) {
+	store := NewSnapshotStore(t.TempDir(), 3)
+	startedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 5; i++ {
+		run := &Run{
+			ID:        newRunID(startedAt.Add(time.Duration(i) * time.Minute)),
+			QueryID:   "foo",
+			Success:   true,
+			RowsCount: i,
+			Result:    &client.Result{Columns: []string{"id"}, Rows: []client.Row{{i}}},
+		}
+		require.NoError(t, store.Save(run))
+	}
+
+	t.Run("list", func(t *testing.T) {
+		runs, total, err := store.List("foo", 0, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		require.Len(t, runs, 2)
+		assert.Equal(t, "20240101T100400.000000000Z", runs[0].ID)
+		assert.Equal(t, 4, runs[0].RowsCount)
+		assert.Nil(t, runs[0].Result)
+		assert.Equal(t, "20240101T100300.000000000Z", runs[1].ID)
+
+		runs, total, err = store.List("bar", 0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 0, total)
+		assert.Empty(t, runs)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		run, err := store.Get("foo", "20240101T100200.000000000Z")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"id"}, run.Result.Columns)
+		assert.Equal(t, fmt.Sprint(2), fmt.Sprint(run.Result.Rows[0][0]))
+
+		_, err = store.Get("foo", "20240101T100000.000000000Z")
+		assert.Equal(t, ErrRunNotFound, err)
+
+		_, err = store.Get("../foo", "20240101T100200.000000000Z")
+		assert.Equal(t, ErrRunNotFound, err)
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		files, err := os.ReadDir(filepath.Join(store.dir, "foo"))
+		require.NoError(t, err)
+		assert.Len(t, files, 6)
+	})
+
+	t.Run("row limit", func(t *testing.T) {
+		store.SetRowLimit(2)
+		defer store.SetRowLimit(0)
+
+		result := &client.Result{
+			Columns: []string{"id"},
+			Rows:    []client.Row{{1}, {2}, {3}},
+			Stats:   &client.ResultStats{RowsCount: 3},
+		}
+		run := &Run{ID: newRunID(startedAt.Add(time.Hour)), QueryID: "bar", RowsCount: 3, Result: result}
+		require.NoError(t, store.Save(run))
+		assert.Len(t, run.Result.Rows, 3)
+
+		saved, err := store.Get("bar", run.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 3, saved.RowsCount)
+		assert.Len(t, saved.Result.Rows, 2)
+		assert.True(t, saved.Result.Stats.Truncated)
+	})
+}
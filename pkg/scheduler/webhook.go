@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	WebhookFormatJSON  = "json"
+	WebhookFormatSlack = "slack"
+)
+
+// Webhook sends alert notifications as HTTP POST requests
+type Webhook struct {
+	url    string
+	format string
+	client *http.Client
+}
+
+func NewWebhook(url string, format string) (*Webhook, error) {
+	if format == "" {
+		format = WebhookFormatJSON
+	}
+	if format != WebhookFormatJSON && format != WebhookFormatSlack {
+		return nil, fmt.Errorf("invalid webhook format: %q", format)
+	}
+
+	return &Webhook{
+		url:    url,
+		format: format,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (w *Webhook) Notify(notification Notification) error {
+	var payload interface{} = notification
+	if w.format == WebhookFormatSlack {
+		payload = slackPayload(notification)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// slackPayload returns a message compatible with Slack incoming webhooks
+func slackPayload(n Notification) map[string]string {
+	title := n.Title
+	if title == "" {
+		title = n.QueryID
+	}
+
+	lines := []string{strings.Join(n.Columns, " | ")}
+	for _, row := range n.Rows {
+		values := make([]string, len(row))
+		for i, value := range row {
+			values[i] = fmt.Sprint(value)
+		}
+		lines = append(lines, strings.Join(values, " | "))
+	}
+
+	text := fmt.Sprintf(
+		"*Alert: %s*\nCondition `%s` is met for bookmark `%s` (%d rows)\n```\n%s\n```",
+		title, n.Alert, n.Bookmark, n.RowsCount, strings.Join(lines, "\n"),
+	)
+
+	return map[string]string{"text": text}
+}
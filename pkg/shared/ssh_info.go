@@ -12,6 +12,12 @@ type SSHInfo struct {
 	Password    string `json:"password,omitempty"`
 	Key         string `json:"key,omitempty"`
 	KeyPassword string `json:"keypassword,omitempty"`
+
+	// Password sources are only read from bookmark files, they are never accepted via API
+	PasswordCommand    string `json:"-" toml:"password_command"`
+	PasswordSecret     string `json:"-" toml:"password_secret"`
+	KeyPasswordCommand string `json:"-" toml:"keypassword_command"`
+	KeyPasswordSecret  string `json:"-" toml:"keypassword_secret"`
 }
 
 func (info SSHInfo) String() string {
@@ -50,6 +50,91 @@ var (
 	//go:embed sql/settings.sql
 	Settings string
 
+	//go:embed sql/index_unused.sql
+	IndexUnused string
+
+	//go:embed sql/index_duplicate.sql
+	IndexDuplicate string
+
+	//go:embed sql/index_invalid.sql
+	IndexInvalid string
+
+	//go:embed sql/index_missing_fk.sql
+	IndexMissingFK string
+
+	//go:embed sql/index_seq_scans.sql
+	IndexSeqScans string
+
+	//go:embed sql/table_bloat.sql
+	TableBloat string
+
+	//go:embed sql/table_bloat_pgstattuple.sql
+	TableBloatPgstattuple string
+
+	//go:embed sql/index_bloat.sql
+	IndexBloat string
+
+	//go:embed sql/index_bloat_pgstattuple.sql
+	IndexBloatPgstattuple string
+
+	//go:embed sql/progress_vacuum.sql
+	ProgressVacuum string
+
+	//go:embed sql/progress_analyze.sql
+	ProgressAnalyze string
+
+	//go:embed sql/progress_create_index.sql
+	ProgressCreateIndex string
+
+	//go:embed sql/progress_cluster.sql
+	ProgressCluster string
+
+	//go:embed sql/progress_copy.sql
+	ProgressCopy string
+
+	//go:embed sql/replication_status.sql
+	replicationStatus string
+
+	//go:embed sql/replication_status_legacy.sql
+	replicationStatusLegacy string
+
+	//go:embed sql/replication_replicas.sql
+	replicationReplicas string
+
+	//go:embed sql/replication_replicas_legacy.sql
+	replicationReplicasLegacy string
+
+	//go:embed sql/replication_slots.sql
+	replicationSlots string
+
+	//go:embed sql/replication_slots_legacy.sql
+	replicationSlotsLegacy string
+
+	//go:embed sql/replication_publications.sql
+	replicationPublications string
+
+	//go:embed sql/replication_subscriptions.sql
+	replicationSubscriptions string
+
+	//go:embed sql/query_stats.sql
+	QueryStats string
+
+	//go:embed sql/query_stats_legacy.sql
+	QueryStatsLegacy string
+
+	//go:embed sql/query_stats_basic.sql
+	QueryStatsBasic string
+
+	// Check if the extension is installed in the current database
+	ExtensionInstalled = "SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = $1)"
+
+	// Installed version of the extension, empty when not installed
+	ExtensionVersion = "SELECT COALESCE((SELECT extversion FROM pg_extension WHERE extname = $1), '')"
+
+	// Backend signalling queries, limited to processes of the current database
+	CancelBackend    = "SELECT pg_cancel_backend(pid) FROM pg_stat_activity WHERE pid = $1 AND datname = current_database()"
+	TerminateBackend = "SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE pid = $1 AND datname = current_database()"
+
 	// Activity queries for specific PG versions
 	Activity = map[string]string{
 		"default": "SELECT * FROM pg_stat_activity WHERE datname = current_database()",
@@ -60,4 +145,84 @@ var (
 		"9.5":     "SELECT datname, query, state, waiting, query_start, state_change, pid, datid, application_name, client_addr FROM pg_stat_activity WHERE datname = current_database()",
 		"9.6":     "SELECT datname, query, state, wait_event, wait_event_type, query_start, state_change, pid, datid, application_name, client_addr FROM pg_stat_activity WHERE datname = current_database()",
 	}
+
+	// Recovery status queries for specific PG versions, WAL functions were renamed in PG 10
+	RecoveryStatus = map[string]string{
+		"default": replicationStatus,
+		"9.1":     "",
+		"9.2":     replicationStatusLegacy,
+		"9.3":     replicationStatusLegacy,
+		"9.4":     replicationStatusLegacy,
+		"9.5":     replicationStatusLegacy,
+		"9.6":     replicationStatusLegacy,
+	}
+
+	// Streaming replicas queries for specific PG versions
+	Replicas = map[string]string{
+		"default": replicationReplicas,
+		"9.1":     "",
+		"9.2":     replicationReplicasLegacy,
+		"9.3":     replicationReplicasLegacy,
+		"9.4":     replicationReplicasLegacy,
+		"9.5":     replicationReplicasLegacy,
+		"9.6":     replicationReplicasLegacy,
+	}
+
+	// Replication slots queries for specific PG versions
+	ReplicationSlots = map[string]string{
+		"default": replicationSlots,
+		"9.1":     "",
+		"9.2":     "",
+		"9.3":     "",
+		"9.4":     replicationSlotsLegacy,
+		"9.5":     replicationSlotsLegacy,
+		"9.6":     replicationSlotsLegacy,
+	}
+
+	// WAL receiver queries for specific PG versions
+	WalReceiver = map[string]string{
+		"default": "SELECT * FROM pg_stat_wal_receiver",
+		"9.1":     "",
+		"9.2":     "",
+		"9.3":     "",
+		"9.4":     "",
+		"9.5":     "",
+	}
+
+	// Logical replication publications queries for specific PG versions
+	Publications = map[string]string{
+		"default": replicationPublications,
+		"9.1":     "",
+		"9.2":     "",
+		"9.3":     "",
+		"9.4":     "",
+		"9.5":     "",
+		"9.6":     "",
+	}
+
+	// Logical replication subscriptions queries for specific PG versions
+	Subscriptions = map[string]string{
+		"default": replicationSubscriptions,
+		"9.1":     "",
+		"9.2":     "",
+		"9.3":     "",
+		"9.4":     "",
+		"9.5":     "",
+		"9.6":     "",
+	}
+
+	// Progress reporting operations available in specific PG versions
+	Progress = map[string][]string{
+		"default": {"vacuum", "analyze", "create_index", "cluster", "copy"},
+		"9.1":     {},
+		"9.2":     {},
+		"9.3":     {},
+		"9.4":     {},
+		"9.5":     {},
+		"9.6":     {"vacuum"},
+		"10":      {"vacuum"},
+		"11":      {"vacuum"},
+		"12":      {"vacuum", "create_index", "cluster"},
+		"13":      {"vacuum", "analyze", "create_index", "cluster"},
+	}
 )
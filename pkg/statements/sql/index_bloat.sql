@@ -0,0 +1,119 @@
+-- Btree index bloat estimation based on the catalog statistics, see
+-- https://github.com/ioguix/pgsql-bloat-estimation for details.
+WITH index_columns AS (
+  SELECT
+    ci.relname AS index_name,
+    ci.reltuples,
+    ci.relpages,
+    i.indrelid AS table_oid,
+    i.indexrelid AS index_oid,
+    COALESCE(substring(array_to_string(ci.reloptions, ' ') FROM 'fillfactor=([0-9]+)')::smallint, 90) AS fillfactor,
+    string_to_array(textin(int2vectorout(i.indkey)), ' ')::int[] AS indkey,
+    generate_series(1, i.indnatts) AS attpos
+  FROM
+    pg_index i
+  JOIN pg_class ci
+    ON ci.oid = i.indexrelid
+  WHERE
+    ci.relam = (SELECT oid FROM pg_am WHERE amname = 'btree')
+    AND ci.relpages > 0
+    -- Indexes that have never been analyzed are skipped, along with indexes without column stats
+    AND ci.reltuples >= 0
+),
+index_attributes AS (
+  SELECT
+    ct.relname AS table_name,
+    ct.relnamespace,
+    ic.index_name,
+    ic.reltuples,
+    ic.relpages,
+    ic.index_oid,
+    ic.fillfactor,
+    COALESCE(a1.attname, a2.attname) AS attname,
+    COALESCE(a1.atttypid, a2.atttypid) AS atttypid,
+    CASE WHEN a1.attnum IS NULL THEN ic.index_name ELSE ct.relname END AS attrelname
+  FROM
+    index_columns ic
+  JOIN pg_class ct
+    ON ct.oid = ic.table_oid
+  LEFT JOIN pg_attribute a1
+    ON ic.indkey[ic.attpos] <> 0
+    AND a1.attrelid = ic.table_oid
+    AND a1.attnum = ic.indkey[ic.attpos]
+  LEFT JOIN pg_attribute a2
+    ON ic.indkey[ic.attpos] = 0
+    AND a2.attrelid = ic.index_oid
+    AND a2.attnum = ic.attpos
+),
+index_stats AS (
+  SELECT
+    n.nspname AS schema_name,
+    ia.table_name,
+    ia.index_name,
+    ia.reltuples,
+    ia.relpages,
+    ia.index_oid,
+    ia.fillfactor,
+    current_setting('block_size')::numeric AS block_size,
+    CASE WHEN version() ~ 'mingw32|64-bit|x86_64|ppc64|ia64|amd64' THEN 8 ELSE 4 END AS max_align,
+    24 AS page_header,
+    16 AS page_opaque_data,
+    CASE WHEN MAX(COALESCE(s.null_frac, 0)) = 0 THEN 8 ELSE 8 + ((32 + 8 - 1) / 8) END AS tuple_header,
+    SUM((1 - COALESCE(s.null_frac, 0)) * COALESCE(s.avg_width, 1024)) AS tuple_data
+  FROM
+    index_attributes ia
+  JOIN pg_namespace n
+    ON n.oid = ia.relnamespace
+  JOIN pg_stats s
+    ON s.schemaname = n.nspname
+    AND s.tablename = ia.attrelname
+    AND s.attname = ia.attname
+  WHERE
+    n.nspname !~ '^pg_(toast|temp)'
+    AND n.nspname NOT IN ('information_schema', 'pg_catalog')
+  GROUP BY
+    1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11
+),
+tuple_sizes AS (
+  SELECT
+    *,
+    (
+      tuple_header + max_align
+      - CASE WHEN tuple_header % max_align = 0 THEN max_align ELSE tuple_header % max_align END
+      + tuple_data + max_align
+      - CASE
+          WHEN tuple_data = 0 THEN 0
+          WHEN tuple_data::integer % max_align = 0 THEN max_align
+          ELSE tuple_data::integer % max_align
+        END
+    )::numeric AS tuple_size
+  FROM
+    index_stats
+),
+expected_pages AS (
+  SELECT
+    *,
+    COALESCE(1 + ceil(reltuples / floor((block_size - page_opaque_data - page_header) * fillfactor / (100 * (4 + tuple_size)::float))), 0) AS estimated_pages
+  FROM
+    tuple_sizes
+)
+SELECT
+  schema_name,
+  table_name,
+  index_name,
+  pg_size_pretty((block_size * relpages)::bigint) AS index_size,
+  (block_size * relpages)::bigint AS index_size_bytes,
+  pg_size_pretty(GREATEST(block_size * (relpages - estimated_pages), 0)::bigint) AS wasted_size,
+  GREATEST(block_size * (relpages - estimated_pages), 0)::bigint AS wasted_bytes,
+  CASE
+    WHEN relpages > estimated_pages
+      THEN ROUND((100 * (relpages - estimated_pages) / relpages)::numeric, 2)
+    ELSE 0
+  END AS bloat_ratio,
+  fillfactor,
+  true AS is_estimated
+FROM
+  expected_pages
+ORDER BY
+  wasted_bytes DESC,
+  index_size_bytes DESC
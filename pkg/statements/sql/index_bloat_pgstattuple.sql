@@ -0,0 +1,60 @@
+WITH index_stats AS (
+  SELECT
+    ns.nspname AS schema_name,
+    ct.relname AS table_name,
+    ci.relname AS index_name,
+    COALESCE(substring(array_to_string(ci.reloptions, ' ') FROM 'fillfactor=([0-9]+)')::smallint, 90) AS fillfactor,
+    current_setting('block_size')::numeric AS block_size,
+    st.index_size,
+    st.leaf_pages,
+    st.empty_pages,
+    st.deleted_pages,
+    NULLIF(st.avg_leaf_density, 'NaN') AS avg_leaf_density
+  FROM
+    pg_index i
+  JOIN pg_class ci
+    ON ci.oid = i.indexrelid
+  JOIN pg_class ct
+    ON ct.oid = i.indrelid
+  JOIN pg_namespace ns
+    ON ns.oid = ci.relnamespace
+  CROSS JOIN LATERAL
+    pgstatindex(ci.oid::regclass) st
+  WHERE
+    ci.relam = (SELECT oid FROM pg_am WHERE amname = 'btree')
+    AND ci.relkind = 'i'
+    AND i.indisvalid
+    AND i.indisready
+    AND ns.nspname !~ '^pg_(toast|temp)'
+    AND ns.nspname NOT IN ('information_schema', 'pg_catalog')
+),
+wasted_space AS (
+  SELECT
+    *,
+    (
+      block_size * (empty_pages + deleted_pages)
+      + COALESCE(block_size * leaf_pages * GREATEST(fillfactor - avg_leaf_density, 0) / 100, 0)
+    )::bigint AS wasted_bytes
+  FROM
+    index_stats
+)
+SELECT
+  schema_name,
+  table_name,
+  index_name,
+  pg_size_pretty(index_size) AS index_size,
+  index_size AS index_size_bytes,
+  pg_size_pretty(wasted_bytes) AS wasted_size,
+  wasted_bytes,
+  CASE
+    WHEN index_size > 0
+      THEN ROUND((100 * wasted_bytes::numeric / index_size), 2)
+    ELSE 0
+  END AS bloat_ratio,
+  fillfactor,
+  false AS is_estimated
+FROM
+  wasted_space
+ORDER BY
+  wasted_bytes DESC,
+  index_size_bytes DESC
@@ -0,0 +1,63 @@
+WITH index_info AS (
+  SELECT
+    i.indexrelid,
+    i.indrelid,
+    i.indkey::text AS columns,
+    i.indclass::text AS opclasses,
+    i.indisunique,
+    i.indisprimary,
+    COALESCE(pg_get_expr(i.indexprs, i.indrelid), '') AS expressions,
+    COALESCE(pg_get_expr(i.indpred, i.indrelid), '') AS predicate,
+    c.relam
+  FROM
+    pg_index i
+  JOIN pg_class c
+    ON c.oid = i.indexrelid
+  JOIN pg_namespace n
+    ON n.oid = c.relnamespace
+  WHERE
+    n.nspname !~ '^pg_(toast|temp)'
+    AND n.nspname NOT IN ('information_schema', 'pg_catalog')
+)
+SELECT
+  n.nspname AS schema_name,
+  t.relname AS table_name,
+  ia.relname AS index_name,
+  ib.relname AS covered_by,
+  CASE WHEN a.columns = b.columns THEN 'duplicate' ELSE 'overlapping' END AS kind,
+  pg_size_pretty(pg_relation_size(a.indexrelid)) AS index_size,
+  pg_relation_size(a.indexrelid) AS index_size_bytes
+FROM
+  index_info a
+JOIN index_info b
+  ON b.indrelid = a.indrelid
+  AND b.indexrelid <> a.indexrelid
+  AND b.relam = a.relam
+  AND b.expressions = a.expressions
+  AND b.predicate = a.predicate
+  AND (
+    (b.columns = a.columns AND b.opclasses = a.opclasses)
+    OR (b.columns LIKE a.columns || ' %' AND b.opclasses LIKE a.opclasses || ' %')
+  )
+JOIN pg_class t
+  ON t.oid = a.indrelid
+JOIN pg_class ia
+  ON ia.oid = a.indexrelid
+JOIN pg_class ib
+  ON ib.oid = b.indexrelid
+JOIN pg_namespace n
+  ON n.oid = t.relnamespace
+WHERE
+  NOT a.indisprimary
+  AND NOT EXISTS (
+    SELECT 1 FROM pg_constraint c WHERE c.conindid = a.indexrelid
+  )
+  AND (
+    -- Index is a prefix of a wider one, unique indexes enforce their own constraint
+    (a.columns <> b.columns AND NOT a.indisunique)
+    -- Identical indexes, keep the unique one or the oldest one
+    OR (a.columns = b.columns AND b.indisunique AND NOT a.indisunique)
+    OR (a.columns = b.columns AND a.indisunique = b.indisunique AND a.indexrelid > b.indexrelid)
+  )
+ORDER BY
+  pg_relation_size(a.indexrelid) DESC
@@ -0,0 +1,19 @@
+SELECT
+  n.nspname AS schema_name,
+  t.relname AS table_name,
+  c.relname AS index_name,
+  pg_size_pretty(pg_relation_size(c.oid)) AS index_size,
+  pg_relation_size(c.oid) AS index_size_bytes,
+  pg_get_indexdef(c.oid) AS index_definition
+FROM
+  pg_index i
+JOIN pg_class c
+  ON c.oid = i.indexrelid
+JOIN pg_class t
+  ON t.oid = i.indrelid
+JOIN pg_namespace n
+  ON n.oid = c.relnamespace
+WHERE
+  NOT i.indisvalid
+ORDER BY
+  pg_relation_size(c.oid) DESC
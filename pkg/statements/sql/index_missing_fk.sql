@@ -0,0 +1,34 @@
+SELECT
+  n.nspname AS schema_name,
+  t.relname AS table_name,
+  c.conname AS constraint_name,
+  c.confrelid::regclass::text AS referenced_table,
+  string_agg(quote_ident(a.attname), ', ' ORDER BY k.pos) AS columns,
+  pg_size_pretty(pg_relation_size(c.conrelid)) AS table_size,
+  pg_relation_size(c.conrelid) AS table_size_bytes
+FROM
+  pg_constraint c
+CROSS JOIN LATERAL
+  unnest(c.conkey) WITH ORDINALITY AS k(attnum, pos)
+JOIN pg_attribute a
+  ON a.attrelid = c.conrelid
+  AND a.attnum = k.attnum
+JOIN pg_class t
+  ON t.oid = c.conrelid
+JOIN pg_namespace n
+  ON n.oid = t.relnamespace
+WHERE
+  c.contype = 'f'
+  AND n.nspname !~ '^pg_(toast|temp)'
+  AND n.nspname NOT IN ('information_schema', 'pg_catalog')
+  AND NOT EXISTS (
+    -- Foreign key columns must be the leading columns of an index
+    SELECT 1 FROM pg_index i
+    WHERE
+      i.indrelid = c.conrelid
+      AND (i.indkey::int2[])[0:array_length(c.conkey, 1) - 1] @> c.conkey
+  )
+GROUP BY
+  n.nspname, t.relname, c.conname, c.confrelid, c.conrelid
+ORDER BY
+  pg_relation_size(c.conrelid) DESC
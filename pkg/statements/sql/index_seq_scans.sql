@@ -0,0 +1,18 @@
+SELECT
+  schemaname AS schema_name,
+  relname AS table_name,
+  seq_scan,
+  seq_tup_read,
+  idx_scan,
+  n_live_tup AS estimated_rows,
+  pg_size_pretty(pg_relation_size(relid)) AS table_size,
+  pg_relation_size(relid) AS table_size_bytes
+FROM
+  pg_stat_user_tables
+WHERE
+  seq_scan > 0
+  AND n_live_tup >= 10000
+  AND seq_scan > COALESCE(idx_scan, 0)
+ORDER BY
+  seq_tup_read DESC
+LIMIT 50
@@ -0,0 +1,20 @@
+SELECT
+  s.schemaname AS schema_name,
+  s.relname AS table_name,
+  s.indexrelname AS index_name,
+  s.idx_scan AS index_scans,
+  pg_size_pretty(pg_relation_size(s.indexrelid)) AS index_size,
+  pg_relation_size(s.indexrelid) AS index_size_bytes
+FROM
+  pg_stat_user_indexes s
+JOIN pg_index i
+  ON i.indexrelid = s.indexrelid
+WHERE
+  s.idx_scan = 0
+  AND NOT i.indisunique
+  AND NOT i.indisprimary
+  AND NOT EXISTS (
+    SELECT 1 FROM pg_constraint c WHERE c.conindid = s.indexrelid
+  )
+ORDER BY
+  pg_relation_size(s.indexrelid) DESC
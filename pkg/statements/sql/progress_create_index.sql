@@ -0,0 +1,23 @@
+SELECT
+  p.*,
+  p.relid::regclass::text AS relation,
+  NULLIF(p.index_relid, 0)::regclass::text AS index_name,
+  CASE
+    WHEN p.phase LIKE '%scanning table%' AND p.blocks_total > 0
+      THEN ROUND(100.0 * p.blocks_done / p.blocks_total, 2)
+    WHEN p.tuples_total > 0
+      THEN ROUND(100.0 * p.tuples_done / p.tuples_total, 2)
+    WHEN p.blocks_total > 0
+      THEN ROUND(100.0 * p.blocks_done / p.blocks_total, 2)
+  END AS progress_pct,
+  a.query_start,
+  EXTRACT(EPOCH FROM now() - a.query_start)::bigint AS duration_sec,
+  a.query
+FROM
+  pg_stat_progress_create_index p
+LEFT JOIN pg_stat_activity a
+  ON a.pid = p.pid
+WHERE
+  p.datname = current_database()
+ORDER BY
+  a.query_start
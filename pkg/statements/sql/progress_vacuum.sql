@@ -0,0 +1,22 @@
+SELECT
+  p.*,
+  p.relid::regclass::text AS relation,
+  CASE
+    WHEN p.heap_blks_total > 0
+      THEN ROUND(100.0 * p.heap_blks_scanned / p.heap_blks_total, 2)
+  END AS progress_pct,
+  CASE
+    WHEN p.heap_blks_total > 0
+      THEN ROUND(100.0 * p.heap_blks_vacuumed / p.heap_blks_total, 2)
+  END AS vacuumed_pct,
+  a.query_start,
+  EXTRACT(EPOCH FROM now() - a.query_start)::bigint AS duration_sec,
+  a.query
+FROM
+  pg_stat_progress_vacuum p
+LEFT JOIN pg_stat_activity a
+  ON a.pid = p.pid
+WHERE
+  p.datname = current_database()
+ORDER BY
+  a.query_start
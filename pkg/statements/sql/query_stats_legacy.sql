@@ -0,0 +1,21 @@
+SELECT
+  s.queryid,
+  pg_get_userbyid(s.userid) AS user_name,
+  d.datname AS database_name,
+  s.query,
+  s.calls,
+  ROUND(s.total_time::numeric, 2) AS total_time_ms,
+  ROUND(s.mean_time::numeric, 2) AS mean_time_ms,
+  ROUND(s.min_time::numeric, 2) AS min_time_ms,
+  ROUND(s.max_time::numeric, 2) AS max_time_ms,
+  s.rows,
+  s.shared_blks_hit,
+  s.shared_blks_read,
+  CASE
+    WHEN s.shared_blks_hit + s.shared_blks_read > 0
+      THEN ROUND(100.0 * s.shared_blks_hit / (s.shared_blks_hit + s.shared_blks_read), 2)
+  END AS hit_ratio
+FROM
+  pg_stat_statements s
+LEFT JOIN pg_database d
+  ON d.oid = s.dbid
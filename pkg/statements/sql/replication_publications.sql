@@ -0,0 +1,10 @@
+SELECT
+  p.*,
+  pg_get_userbyid(p.pubowner) AS owner,
+  (
+    SELECT COUNT(1) FROM pg_publication_tables pt WHERE pt.pubname = p.pubname
+  ) AS tables_count
+FROM
+  pg_publication p
+ORDER BY
+  p.pubname
@@ -0,0 +1,30 @@
+SELECT
+  r.pid,
+  r.usename,
+  r.application_name,
+  r.client_addr,
+  r.state,
+  r.sync_state,
+  r.sent_lsn,
+  r.write_lsn,
+  r.flush_lsn,
+  r.replay_lsn,
+  pg_wal_lsn_diff(wal.lsn, r.sent_lsn) AS sent_lag_bytes,
+  pg_wal_lsn_diff(wal.lsn, r.replay_lsn) AS replay_lag_bytes,
+  pg_size_pretty(pg_wal_lsn_diff(wal.lsn, r.replay_lsn)) AS replay_lag_size,
+  EXTRACT(EPOCH FROM r.write_lag) AS write_lag_sec,
+  EXTRACT(EPOCH FROM r.flush_lag) AS flush_lag_sec,
+  EXTRACT(EPOCH FROM r.replay_lag) AS replay_lag_sec,
+  r.backend_start
+FROM
+  pg_stat_replication r
+CROSS JOIN (
+  SELECT
+    CASE
+      WHEN pg_is_in_recovery() THEN pg_last_wal_receive_lsn()
+      ELSE pg_current_wal_lsn()
+    END AS lsn
+) wal
+ORDER BY
+  r.application_name,
+  r.pid
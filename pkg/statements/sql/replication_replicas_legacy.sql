@@ -0,0 +1,30 @@
+SELECT
+  r.pid,
+  r.usename,
+  r.application_name,
+  r.client_addr,
+  r.state,
+  r.sync_state,
+  r.sent_location AS sent_lsn,
+  r.write_location AS write_lsn,
+  r.flush_location AS flush_lsn,
+  r.replay_location AS replay_lsn,
+  pg_xlog_location_diff(wal.lsn, r.sent_location) AS sent_lag_bytes,
+  pg_xlog_location_diff(wal.lsn, r.replay_location) AS replay_lag_bytes,
+  pg_size_pretty(pg_xlog_location_diff(wal.lsn, r.replay_location)) AS replay_lag_size,
+  NULL::numeric AS write_lag_sec,
+  NULL::numeric AS flush_lag_sec,
+  NULL::numeric AS replay_lag_sec,
+  r.backend_start
+FROM
+  pg_stat_replication r
+CROSS JOIN (
+  SELECT
+    CASE
+      WHEN pg_is_in_recovery() THEN pg_last_xlog_receive_location()
+      ELSE pg_current_xlog_location()
+    END AS lsn
+) wal
+ORDER BY
+  r.application_name,
+  r.pid
@@ -0,0 +1,15 @@
+SELECT
+  s.*,
+  pg_xlog_location_diff(wal.lsn, s.restart_lsn) AS retained_wal_bytes,
+  pg_size_pretty(pg_xlog_location_diff(wal.lsn, s.restart_lsn)) AS retained_wal_size
+FROM
+  pg_replication_slots s
+CROSS JOIN (
+  SELECT
+    CASE
+      WHEN pg_is_in_recovery() THEN pg_last_xlog_receive_location()
+      ELSE pg_current_xlog_location()
+    END AS lsn
+) wal
+ORDER BY
+  retained_wal_bytes DESC NULLS LAST
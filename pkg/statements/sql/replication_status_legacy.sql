@@ -0,0 +1,15 @@
+SELECT
+  pg_is_in_recovery() AS in_recovery,
+  CASE WHEN pg_is_in_recovery() THEN 'standby' ELSE 'primary' END AS server_role,
+  CASE WHEN NOT pg_is_in_recovery() THEN pg_current_xlog_location() END AS current_lsn,
+  pg_last_xlog_receive_location() AS receive_lsn,
+  pg_last_xlog_replay_location() AS replay_lsn,
+  pg_last_xact_replay_timestamp() AS last_replay_time,
+  CASE
+    WHEN pg_is_in_recovery()
+      THEN pg_xlog_location_diff(pg_last_xlog_receive_location(), pg_last_xlog_replay_location())
+  END AS replay_lag_bytes,
+  CASE
+    WHEN pg_is_in_recovery()
+      THEN EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+  END AS replay_lag_sec
@@ -0,0 +1,11 @@
+SELECT
+  s.*,
+  sub.subenabled AS enabled,
+  sub.subpublications AS publications,
+  EXTRACT(EPOCH FROM now() - s.latest_end_time) AS lag_sec
+FROM
+  pg_stat_subscription s
+LEFT JOIN pg_subscription sub
+  ON sub.oid = s.subid
+ORDER BY
+  s.subname
@@ -0,0 +1,87 @@
+-- Table bloat estimation based on the catalog statistics, see
+-- https://github.com/ioguix/pgsql-bloat-estimation for details.
+WITH table_stats AS (
+  SELECT
+    tbl.oid AS table_oid,
+    ns.nspname AS schema_name,
+    tbl.relname AS table_name,
+    tbl.reltuples,
+    tbl.relpages AS heap_pages,
+    COALESCE(toast.relpages, 0) AS toast_pages,
+    COALESCE(toast.reltuples, 0) AS toast_tuples,
+    COALESCE(substring(array_to_string(tbl.reloptions, ' ') FROM 'fillfactor=([0-9]+)')::smallint, 100) AS fillfactor,
+    current_setting('block_size')::numeric AS block_size,
+    CASE WHEN version() ~ 'mingw32|64-bit|x86_64|ppc64|ia64|amd64' THEN 8 ELSE 4 END AS max_align,
+    24 AS page_header,
+    23 + CASE WHEN MAX(COALESCE(s.null_frac, 0)) > 0 THEN (7 + COUNT(s.attname)) / 8 ELSE 0::int END AS tuple_header,
+    -- Estimates make no sense for tables that have never been analyzed
+    tbl.reltuples >= 0 AND COUNT(s.attname) > 0 AS is_analyzed,
+    SUM((1 - COALESCE(s.null_frac, 0)) * COALESCE(s.avg_width, 0)) AS tuple_data
+  FROM
+    pg_attribute att
+  JOIN pg_class tbl
+    ON tbl.oid = att.attrelid
+  JOIN pg_namespace ns
+    ON ns.oid = tbl.relnamespace
+  LEFT JOIN pg_stats s
+    ON s.schemaname = ns.nspname
+    AND s.tablename = tbl.relname
+    AND s.inherited = false
+    AND s.attname = att.attname
+  LEFT JOIN pg_class toast
+    ON toast.oid = tbl.reltoastrelid
+  WHERE
+    NOT att.attisdropped
+    AND att.attnum > 0
+    AND tbl.relkind IN ('r', 'm')
+    AND ns.nspname !~ '^pg_(toast|temp)'
+    AND ns.nspname NOT IN ('information_schema', 'pg_catalog')
+  GROUP BY
+    1, 2, 3, 4, 5, 6, 7, 8, 9, 10
+),
+tuple_sizes AS (
+  SELECT
+    *,
+    (
+      4 + tuple_header + tuple_data + (2 * max_align)
+      - CASE WHEN tuple_header % max_align = 0 THEN max_align ELSE tuple_header % max_align END
+      - CASE WHEN ceil(tuple_data)::int % max_align = 0 THEN max_align ELSE ceil(tuple_data)::int % max_align END
+    ) AS tuple_size,
+    heap_pages + toast_pages AS total_pages
+  FROM
+    table_stats
+),
+expected_pages AS (
+  SELECT
+    *,
+    ceil(reltuples / ((block_size - page_header) * fillfactor / (tuple_size * 100))) + ceil(toast_tuples / 4) AS estimated_pages
+  FROM
+    tuple_sizes
+)
+SELECT
+  schema_name,
+  table_name,
+  pg_size_pretty((block_size * total_pages)::bigint) AS table_size,
+  (block_size * total_pages)::bigint AS table_size_bytes,
+  CASE
+    WHEN is_analyzed
+      THEN pg_size_pretty(GREATEST(block_size * (total_pages - estimated_pages), 0)::bigint)
+  END AS wasted_size,
+  CASE
+    WHEN is_analyzed
+      THEN GREATEST(block_size * (total_pages - estimated_pages), 0)::bigint
+  END AS wasted_bytes,
+  CASE
+    WHEN NOT is_analyzed THEN NULL
+    WHEN total_pages > 0 AND total_pages > estimated_pages
+      THEN ROUND((100 * (total_pages - estimated_pages) / total_pages)::numeric, 2)
+    ELSE 0
+  END AS bloat_ratio,
+  fillfactor,
+  true AS is_estimated,
+  is_analyzed
+FROM
+  expected_pages
+ORDER BY
+  wasted_bytes DESC NULLS LAST,
+  table_size_bytes DESC
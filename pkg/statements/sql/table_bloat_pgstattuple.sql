@@ -0,0 +1,39 @@
+WITH table_stats AS (
+  SELECT
+    ns.nspname AS schema_name,
+    tbl.relname AS table_name,
+    COALESCE(substring(array_to_string(tbl.reloptions, ' ') FROM 'fillfactor=([0-9]+)')::smallint, 100) AS fillfactor,
+    st.table_len,
+    st.dead_tuple_len,
+    st.approx_free_space
+  FROM
+    pg_class tbl
+  JOIN pg_namespace ns
+    ON ns.oid = tbl.relnamespace
+  CROSS JOIN LATERAL
+    pgstattuple_approx(tbl.oid) st
+  WHERE
+    tbl.relkind IN ('r', 'm')
+    AND ns.nspname !~ '^pg_(toast|temp)'
+    AND ns.nspname NOT IN ('information_schema', 'pg_catalog')
+)
+SELECT
+  schema_name,
+  table_name,
+  pg_size_pretty(table_len) AS table_size,
+  table_len AS table_size_bytes,
+  pg_size_pretty(dead_tuple_len + approx_free_space) AS wasted_size,
+  (dead_tuple_len + approx_free_space)::bigint AS wasted_bytes,
+  CASE
+    WHEN table_len > 0
+      THEN ROUND((100 * (dead_tuple_len + approx_free_space)::numeric / table_len), 2)
+    ELSE 0
+  END AS bloat_ratio,
+  fillfactor,
+  false AS is_estimated,
+  true AS is_analyzed
+FROM
+  table_stats
+ORDER BY
+  wasted_bytes DESC,
+  table_size_bytes DESC
@@ -0,0 +1,95 @@
+package watcher
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDelay is the time to wait for more changes before calling the callback,
+// editors usually produce several events when saving a file
+const DefaultDelay = 200 * time.Millisecond
+
+// Watcher calls the callback once files in the watched directory change
+type Watcher struct {
+	fs       *fsnotify.Watcher
+	delay    time.Duration
+	onChange func()
+	onError  func(error)
+	timer    *time.Timer
+	mu       sync.Mutex
+	done     chan struct{}
+}
+
+// New starts watching the directory, changes within the delay are reported once
+func New(dir string, delay time.Duration, onChange func(), onError func(error)) (*Watcher, error) {
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fs.Add(dir); err != nil {
+		fs.Close()
+		return nil, err
+	}
+
+	w := &Watcher{
+		fs:       fs,
+		delay:    delay,
+		onChange: onChange,
+		onError:  onError,
+		done:     make(chan struct{}),
+	}
+	go w.run()
+
+	return w, nil
+}
+
+// Close stops watching the directory
+func (w *Watcher) Close() error {
+	w.mu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.mu.Unlock()
+
+	err := w.fs.Close()
+	<-w.done
+	return err
+}
+
+func (w *Watcher) run() {
+	defer close(w.done)
+
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			// Access time and permission changes do not affect file contents
+			if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			w.schedule()
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			if w.onError != nil {
+				w.onError(err)
+			}
+		}
+	}
+}
+
+func (w *Watcher) schedule() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(w.delay, w.onChange)
+}
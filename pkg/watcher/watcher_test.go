@@ -0,0 +1,35 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	changes := atomic.Int32{}
+
+	w, err := New(dir, 50*time.Millisecond, func() { changes.Add(1) }, nil)
+	require.NoError(t, err)
+	defer w.Close()
+
+	// Multiple changes within the delay are reported once
+	for i := 0; i < 3; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "foo.toml"), []byte("foo"), 0644))
+	}
+	assert.Eventually(t, func() bool { return changes.Load() == 1 }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "foo.toml")))
+	assert.Eventually(t, func() bool { return changes.Load() == 2 }, time.Second, 10*time.Millisecond)
+}
+
+func TestWatcherMissingDir(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing"), DefaultDelay, func() {}, nil)
+	assert.Error(t, err)
+}
@@ -111,11 +111,21 @@ function getTablesStats(cb)                 { apiCall("get", "/tables_stats", {}
 function getFunction(id, cb)                { apiCall("get", "/functions/" + id, {}, cb); }
 function getHistory(cb)                     { apiCall("get", "/history", {}, cb); }
 function getBookmarks(cb)                   { apiCall("get", "/bookmarks", {}, cb); }
-function executeQuery(query, cb)            { apiCall("post", "/query", { query: query }, cb); }
+function getServices(cb)                    { apiCall("get", "/services", {}, cb); }
 function explainQuery(query, cb)            { apiCall("post", "/explain", { query: query }, cb); }
 function analyzeQuery(query, cb)            { apiCall("post", "/analyze", { query: query }, cb); }
 function disconnect(cb)                     { apiCall("post", "/disconnect", {}, cb); }
 
+function executeQuery(query, cb) {
+  apiCall("post", "/query", { query: query }, function(data) {
+    // Query planner estimates exceed configured limits
+    if (data.confirmation_required && confirm(data.error + "\n\nRun the query anyway?")) {
+      return apiCall("post", "/query", { query: query, confirm: true }, cb);
+    }
+    cb(data);
+  });
+}
+
 function encodeQuery(query) {
   return Base64.encode(query).replace(/\+/g, "-").replace(/\//g, "_").replace(/=/g, ".");
 }
@@ -189,6 +199,11 @@ function buildSchemaSection(name, objects) {
 function loadLocalQueries() {
   if (!appFeatures.local_queries) return;
 
+  $("body").on("click", "a.save-local-query", function(e) {
+    e.preventDefault();
+    saveLocalQuery();
+  });
+
   $("body").on("click", "a.load-local-query", function(e) {
     var id = $(this).data("id");
 
@@ -208,11 +223,44 @@ function loadLocalQueries() {
       $("<li><a href='#' class='load-local-query' data-id='" + item.id + "'>" + title + "</a></li>").appendTo(container);
     });
 
-    if (resp.length > 0) $("#load-local-query").prop("disabled", "");
+    if (appFeatures.saved_queries) {
+      if (resp.length > 0) $("<li class='divider'></li>").appendTo(container);
+      $("<li><a href='#' class='save-local-query'>Save current query...</a></li>").appendTo(container);
+    }
+
+    if (resp.length > 0 || appFeatures.saved_queries) $("#load-local-query").prop("disabled", "");
     $("#load-query-dropdown").show();
   });
 }
 
+function saveLocalQuery() {
+  var query = editor.getValue().trim();
+  if (query.length == 0) {
+    alert("Please specify the query");
+    return;
+  }
+
+  var title = prompt("Query title");
+  if (!title) return;
+
+  $.ajax({
+    url: "api/saved_queries",
+    method: "post",
+    contentType: "application/json",
+    data: JSON.stringify({ title: title, query: query }),
+    headers: { "x-session-id": getSessionId() },
+    success: function(resp) {
+      var container = $("#load-query-dropdown").find(".dropdown-menu");
+      var link = $("<a href='#' class='load-local-query'></a>").attr("data-id", resp.id).text(resp.title);
+      $("<li></li>").append(link).prependTo(container);
+    },
+    error: function(xhr) {
+      var resp = xhr.responseJSON || {};
+      alert("Unable to save query: " + (resp.error || xhr.statusText));
+    }
+  });
+}
+
 function loadSchemas() {
   $("#objects").html("");
 
@@ -480,7 +528,11 @@ function buildTable(results, sortColumn, sortOrder, options) {
 
   // Show number of rows rendered on the page
   if (results.stats) {
-    $("#result-rows-count").html(results.stats.rows_count + " rows in " + results.stats.query_duration_ms + " ms");
+    var rowsCount = results.stats.rows_count + " rows";
+    if (results.stats.truncated) {
+      rowsCount = "First " + rowsCount + " (truncated, export for full result)";
+    }
+    $("#result-rows-count").html(rowsCount + " in " + results.stats.query_duration_ms + " ms");
   } else {
     $("#result-rows-count").html(results.rows.length + " rows");
   }
@@ -503,11 +555,19 @@ function showQueryHistory() {
   getHistory(function(data) {
     var rows = [];
 
-    for(i in data) {
-      rows.unshift([parseInt(i) + 1, data[i].query, data[i].timestamp]);
+    for(i in data.records) {
+      var record = data.records[i];
+      rows.push([
+        data.total - data.offset - parseInt(i),
+        record.query,
+        record.timestamp,
+        record.duration_ms,
+        record.rows_count,
+        record.success ? "success" : record.error
+      ]);
     }
 
-    buildTable({ columns: ["id", "query", "timestamp"], rows: rows });
+    buildTable({ columns: ["id", "query", "timestamp", "duration_ms", "rows", "status"], rows: rows });
 
     setCurrentTab("table_history");
     $("#input").hide();
@@ -913,7 +973,7 @@ function runExplain() {
   }
 
   explainQuery(query, function(data) {
-    buildTable(data);
+    buildTable(data.plan ? buildPlanResults(data) : data);
 
     hideQueryProgressMessage();
     $("#input").show();
@@ -933,7 +993,7 @@ function runAnalyze() {
   }
 
   analyzeQuery(query, function(data) {
-    buildTable(data);
+    buildTable(data.plan ? buildPlanResults(data) : data);
 
     hideQueryProgressMessage();
     $("#input").show();
@@ -942,6 +1002,37 @@ function runAnalyze() {
   });
 }
 
+// Convert structured query plan into a flat table, one row per plan node
+function buildPlanResults(data) {
+  var columns = ["node", "relation", "cost", "rows", "time_ms", "buffers", "warnings"];
+  var rows = [];
+
+  var addNode = function(node, depth) {
+    var name = new Array(depth + 1).join("    ") + (depth > 0 ? "-> " : "") + node.node_type;
+    var relation = node.relation_name || node.index_name || "";
+    var rowsInfo = node.plan_rows;
+    var time = "";
+    var buffers = "";
+
+    if (data.analyzed) {
+      rowsInfo = node.actual_rows + " (est. " + node.plan_rows + (node.rows_misestimated ? ", misestimated" : "") + ")";
+      time = node.exclusive_time_ms.toFixed(3) + " / " + node.inclusive_time_ms.toFixed(3);
+      buffers = "hit=" + node.buffers.shared_hit + " read=" + node.buffers.shared_read;
+    }
+
+    var warnings = node.warnings.map(function(w) { return w.message; }).join("; ");
+
+    rows.push([name, relation, node.startup_cost + ".." + node.total_cost, rowsInfo, time, buffers, warnings]);
+
+    node.plans.forEach(function(child) {
+      addNode(child, depth + 1);
+    });
+  };
+
+  addNode(data.plan, 0);
+  return { columns: columns, rows: rows };
+}
+
 function generateURL(path, params) {
   var url = new URL(window.location.href.split("#")[0]);
 
@@ -1152,6 +1243,35 @@ function showConnectionSettings() {
         $(".bookmarks").hide();
       }
     }
+
+    loadServices();
+  });
+}
+
+function loadServices() {
+  if (!appFeatures.services) return;
+
+  getServices(function(data) {
+    if (data.error) {
+      console.log("Error while fetching services:", data.error);
+      return;
+    }
+
+    $("#connection_bookmarks optgroup.services").remove();
+    if (data.length == 0) return;
+
+    if ($("#connection_bookmarks option").length == 0) {
+      $("<option value=''>Select a bookmarked database to connect to</option>").appendTo("#connection_bookmarks");
+    }
+
+    // Services are listed after bookmarks
+    var group = $("<optgroup class='services' label='Services'></optgroup>");
+    for (name of data) {
+      $("<option data-service='true'></option>").attr("value", name).text(name).appendTo(group);
+    }
+    group.appendTo("#connection_bookmarks");
+
+    $(".bookmarks").show();
   });
 }
 
@@ -1824,7 +1944,10 @@ $(document).ready(function() {
     var params = {};
     var bookmarkID = $.trim($("#connection_bookmarks").val());
 
-    if (bookmarkID != "") {
+    if (bookmarkID != "" && $("#connection_bookmarks option:selected").data("service")) {
+      params["service"] = bookmarkID;
+    }
+    else if (bookmarkID != "") {
       params["bookmark_id"] = $("#connection_bookmarks").val();
     }
     else {